@@ -0,0 +1,17 @@
+module github.com/sylus/drupal-operator
+
+go 1.15
+
+require (
+	github.com/iancoleman/strcase v0.0.0-20180726023541-3605ed457bf7
+	github.com/imdario/mergo v0.3.10
+	github.com/onsi/ginkgo v1.14.1
+	github.com/onsi/gomega v1.10.2
+	github.com/prometheus/client_golang v1.7.1
+	golang.org/x/net v0.0.0-20201110031124-69a78807bb2b
+	k8s.io/api v0.19.16
+	k8s.io/apimachinery v0.19.16
+	k8s.io/client-go v0.19.16
+	sigs.k8s.io/controller-runtime v0.7.2
+	sigs.k8s.io/controller-tools v0.4.1
+)
@@ -0,0 +1,24 @@
+// +build tools
+
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tools tracks the code generators and test runner in go.mod
+package tools
+
+import (
+	_ "github.com/onsi/ginkgo/ginkgo"
+	_ "sigs.k8s.io/controller-tools/cmd/controller-gen"
+)
@@ -15,8 +15,38 @@ limitations under the License.
 
 package v1beta1
 
+import (
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultMediaMountPath = "/var/www/html/sites/default/files"
+	defaultSearchImage    = "solr:7.7-slim"
+	defaultSearchPort     = 8983
+	defaultVarnishImage   = "varnish:6.0"
+	// the kube-dns Service keeps its name under CoreDNS, and nginx resolves
+	// it through the search domains of the pod
+	defaultNginxResolver = "kube-dns.kube-system.svc"
+
+	defaultPHPFPMExporterImage = "hipages/php-fpm_exporter:2"
+	defaultNginxExporterImage  = "nginx/nginx-prometheus-exporter:0.11.0"
+)
+
 var (
 	oneReplica int32 = 1
+
+	defaultCacheImages = map[CacheBackend]string{
+		RedisCacheBackend:    "redis:5-alpine",
+		MemcacheCacheBackend: "memcached:1.5-alpine",
+	}
+
+	defaultBypassCookies  = []string{"SESS", "SSESS", "NO_CACHE"}
+	defaultPathType       = networkingv1.PathTypePrefix
+	defaultPurgeAllowFrom = []string{"127.0.0.1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
 )
 
 // nolint: golint
@@ -27,4 +57,167 @@ func SetDefaults_DropletSpec(spec *DropletSpec) {
 	if spec.Nginx.Replicas == nil || *spec.Nginx.Replicas < 1 {
 		spec.Nginx.Replicas = &oneReplica
 	}
+	if spec.CacheSpec != nil {
+		setCacheSpecDefaults(spec.CacheSpec)
+	}
+	if spec.SearchSpec != nil {
+		setSearchSpecDefaults(spec.SearchSpec)
+	}
+	if spec.MonitoringSpec != nil {
+		setMonitoringSpecDefaults(spec.MonitoringSpec)
+	}
+	if spec.Nginx.CacheSpec != nil {
+		setPageCacheSpecDefaults(spec.Nginx.CacheSpec)
+	}
+	if len(spec.Nginx.Resolver) == 0 {
+		spec.Nginx.Resolver = defaultNginxResolver
+	}
+	if len(spec.Drupal.SettingsMode) == 0 {
+		spec.Drupal.SettingsMode = IncludeSettingsMode
+	}
+	for i := range spec.Sites {
+		setSiteSpecDefaults(&spec.Sites[i])
+	}
+	if len(spec.RoutingSpec.Mode) == 0 {
+		spec.RoutingSpec.Mode = IngressRoutingMode
+	}
+	if spec.TLSSpec != nil && spec.TLSSpec.IssuerRef != nil {
+		setCertIssuerRefDefaults(spec.TLSSpec.IssuerRef)
+	}
+	if spec.IngressSpec != nil {
+		setIngressSpecDefaults(spec.IngressSpec)
+	}
+	if len(spec.Topology) == 0 {
+		spec.Topology = SplitTopology
+		if code := spec.Drupal.CodeVolumeSpec; code != nil && code.GitDir != nil && code.GitDir.Artifact == nil {
+			// nginx shares the clone by running in the drupal pods
+			spec.Topology = CombinedTopology
+		}
+	}
+	if spec.Drupal.MediaVolumeSpec != nil && spec.Drupal.MediaVolumeSpec.S3VolumeSource != nil {
+		setS3VolumeSourceDefaults(spec.Drupal.MediaVolumeSpec.S3VolumeSource)
+	}
+	if spec.Drupal.PrivateFilesVolumeSpec != nil && spec.Drupal.PrivateFilesVolumeSpec.S3VolumeSource != nil {
+		setS3VolumeSourceDefaults(spec.Drupal.PrivateFilesVolumeSpec.S3VolumeSource)
+	}
+	if spec.Drupal.MediaVolumeSpec != nil && len(spec.Drupal.MediaVolumeSpec.MountPath) == 0 {
+		spec.Drupal.MediaVolumeSpec.MountPath = defaultMediaMountPath
+	}
+}
+
+func setMonitoringSpecDefaults(monitoring *MonitoringSpec) {
+	if len(monitoring.PHPFPMExporterImage) == 0 {
+		monitoring.PHPFPMExporterImage = defaultPHPFPMExporterImage
+	}
+	if len(monitoring.NginxExporterImage) == 0 {
+		monitoring.NginxExporterImage = defaultNginxExporterImage
+	}
+}
+
+func setSearchSpecDefaults(search *SearchSpec) {
+	if len(search.ServerID) == 0 {
+		search.ServerID = "solr"
+	}
+	if len(search.Core) == 0 {
+		search.Core = "drupal"
+	}
+	if search.External == nil && search.Managed == nil {
+		search.Managed = &ManagedSearchSpec{}
+	}
+	if search.Managed != nil && len(search.Managed.Image) == 0 {
+		search.Managed.Image = defaultSearchImage
+	}
+	if search.External != nil {
+		if len(search.External.Scheme) == 0 {
+			search.External.Scheme = "http"
+		}
+		if search.External.Port == 0 {
+			search.External.Port = defaultSearchPort
+		}
+		if len(search.External.Path) == 0 {
+			search.External.Path = "/"
+		}
+	}
+}
+
+func setSiteSpecDefaults(site *SiteSpec) {
+	if len(site.Database) == 0 {
+		site.Database = site.Name
+	}
+	if len(site.FilesPath) == 0 {
+		site.FilesPath = "sites/default/files/" + site.Name
+	}
+}
+
+func setCertIssuerRefDefaults(ref *CertIssuerRef) {
+	if len(ref.Kind) == 0 {
+		ref.Kind = "Issuer"
+	}
+	if len(ref.Group) == 0 {
+		ref.Group = "cert-manager.io"
+	}
+}
+
+func setIngressSpecDefaults(ingress *IngressSpec) {
+	if len(ingress.Path) == 0 {
+		ingress.Path = "/"
+	}
+	if ingress.PathType == nil {
+		pathType := defaultPathType
+		ingress.PathType = &pathType
+	}
+	for i := range ingress.ExtraPaths {
+		if ingress.ExtraPaths[i].PathType == nil {
+			pathType := defaultPathType
+			ingress.ExtraPaths[i].PathType = &pathType
+		}
+	}
+}
+
+func setPageCacheSpecDefaults(cache *PageCacheSpec) {
+	if cache.TTL == nil {
+		cache.TTL = &metav1.Duration{Duration: 10 * time.Minute}
+	}
+	if cache.NotFoundTTL == nil {
+		cache.NotFoundTTL = &metav1.Duration{Duration: time.Minute}
+	}
+	if cache.Size == nil {
+		size := resource.MustParse("1Gi")
+		cache.Size = &size
+	}
+	if len(cache.BypassCookies) == 0 {
+		cache.BypassCookies = append([]string{}, defaultBypassCookies...)
+	}
+	if cache.Purge != nil && len(cache.Purge.AllowFrom) == 0 {
+		cache.Purge.AllowFrom = append([]string{}, defaultPurgeAllowFrom...)
+	}
+	if cache.Varnish != nil {
+		if len(cache.Varnish.Image) == 0 {
+			cache.Varnish.Image = defaultVarnishImage
+		}
+		if cache.Varnish.Replicas == nil || *cache.Varnish.Replicas < 1 {
+			cache.Varnish.Replicas = &oneReplica
+		}
+	}
+}
+
+func setCacheSpecDefaults(cache *CacheSpec) {
+	if cache.External == nil && cache.Managed == nil {
+		cache.Managed = &ManagedCacheSpec{}
+	}
+	if cache.Managed != nil && len(cache.Managed.Image) == 0 {
+		cache.Managed.Image = defaultCacheImages[cache.Backend]
+	}
+	if cache.External != nil && cache.External.Port == 0 {
+		cache.External.Port = cache.Backend.DefaultPort()
+	}
+}
+
+func setS3VolumeSourceDefaults(s3 *S3VolumeSource) {
+	if len(s3.Region) == 0 {
+		s3.Region = "us-east-1"
+	}
+	if len(s3.Module) == 0 {
+		s3.Module = "s3fs"
+	}
 }
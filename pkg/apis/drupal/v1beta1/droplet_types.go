@@ -17,7 +17,10 @@ package v1beta1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -29,6 +32,92 @@ type SecretRef string
 // Domain represents a valid domain name
 type Domain string
 
+// Topology represents how the drupal and nginx tiers get deployed
+type Topology string
+
+const (
+	// SplitTopology runs drupal and nginx as separate Deployments
+	SplitTopology Topology = "split"
+	// CombinedTopology runs nginx as a sidecar in the drupal pods
+	CombinedTopology Topology = "combined"
+)
+
+// SiteSpec defines a site of a Drupal multisite
+type SiteSpec struct {
+	// Name of the site, used as its directory under sites/
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Domains for which the site answers
+	// +kubebuilder:validation:MinItems=1
+	Domains []Domain `json:"domains"`
+	// Database is the name of the site's database, on the database server
+	// of the droplet. Defaults to the site name
+	// +optional
+	Database string `json:"database,omitempty"`
+	// FilesPath is the public files directory of the site, relative to the
+	// webroot. Defaults to sites/default/files/<name>, on the media volume.
+	// +optional
+	FilesPath string `json:"filesPath,omitempty"`
+	// InstallProfile installs the site with the given profile, unless it is
+	// installed already
+	// +optional
+	InstallProfile string `json:"installProfile,omitempty"`
+}
+
+// SettingsMode represents how the generated settings get into the site
+type SettingsMode string
+
+const (
+	// IncludeSettingsMode mounts the generated settings.k8s.php next to the
+	// settings.php of the site, which has to include it
+	IncludeSettingsMode SettingsMode = "include"
+	// OverrideSettingsMode additionally mounts a settings.php including
+	// settings.k8s.php over the settings.php of the site
+	OverrideSettingsMode SettingsMode = "override"
+)
+
+// WWWNormalization represents how the www. prefix of a domain gets normalized
+type WWWNormalization string
+
+const (
+	// AddWWW redirects to the domain prefixed with www.
+	AddWWW WWWNormalization = "add"
+	// RemoveWWW redirects to the domain without its www. prefix
+	RemoveWWW WWWNormalization = "remove"
+)
+
+// DomainOptions defines how requests to one of the site's domains get
+// redirected. Redirects keep the path and query of the request.
+type DomainOptions struct {
+	// Domain the options apply to, one of spec.domains
+	Domain Domain `json:"domain"`
+	// RedirectTo main redirects the domain to the main domain, the first
+	// domain of its site
+	// +kubebuilder:validation:Enum=main
+	// +optional
+	RedirectTo string `json:"redirectTo,omitempty"`
+	// WWW adds or removes the www. prefix by redirecting. The resulting
+	// domain should be one of spec.domains as well.
+	// +kubebuilder:validation:Enum=add;remove
+	// +optional
+	WWW WWWNormalization `json:"www,omitempty"`
+	// ForceHTTPS redirects plain http requests to https
+	// +optional
+	ForceHTTPS bool `json:"forceHTTPS,omitempty"`
+}
+
+// RoutingMode represents how traffic gets routed to the site
+type RoutingMode string
+
+const (
+	// IngressRoutingMode routes traffic with an Ingress
+	IngressRoutingMode RoutingMode = "ingress"
+	// GatewayRoutingMode routes traffic with a Gateway API HTTPRoute
+	GatewayRoutingMode RoutingMode = "gateway"
+	// NoRoutingMode leaves routing traffic to the site to the user
+	NoRoutingMode RoutingMode = "none"
+)
+
 // DropletSpec defines the desired state of Droplet
 type DropletSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -37,12 +126,29 @@ type DropletSpec struct {
 	// The first item is set as the "main domain" (eg. DRUPAL_HOME and DRUPAL_SITEURL constants).
 	// +kubebuilder:validation:MinItems=1
 	Domains []Domain `json:"domains"`
+	// Sites turns the droplet into a Drupal multisite. Every site gets its
+	// own domains, database and files directory, sharing code and image
+	// with the default site.
+	// +optional
+	Sites []SiteSpec `json:"sites,omitempty"`
+	// DomainOptions configure redirects per domain
+	// +optional
+	DomainOptions []DomainOptions `json:"domainOptions,omitempty"`
 	// DrupalSpec for related configuration overrides
 	// +optional
 	Drupal DrupalSpec `json:"drupal,omitempty"`
 	// NginxSpec for related configuration overrides
 	// +optional
 	Nginx NginxSpec `json:"nginx,omitempty"`
+	// Topology specifies how drupal and nginx get deployed. In split topology
+	// they run as separate Deployments talking over a Service, in combined
+	// topology nginx runs as a sidecar container in the drupal pods. Code
+	// cloned from git is only shared with split nginx pods through a built
+	// artifact. Defaults to split, or to combined for code cloned from git
+	// without an artifact.
+	// +kubebuilder:validation:Enum=split;combined
+	// +optional
+	Topology Topology `json:"topology,omitempty"`
 	// ServiceAccountName is the name of the ServiceAccount to use to run this
 	// site's pods
 	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/
@@ -51,9 +157,203 @@ type DropletSpec struct {
 	// TLSSecretRef a secret containing the TLS certificates for this site.
 	// +optional
 	TLSSecretRef SecretRef `json:"tlsSecretRef,omitempty"`
+	// TLSSpec configures certificates issued by cert-manager. It takes
+	// precedence over TLSSecretRef.
+	// +optional
+	TLSSpec *TLSSpec `json:"tls,omitempty"`
+	// ReverseProxyAddresses lists the addresses or CIDRs of the proxies in
+	// front of Drupal, eg. the ingress controller and nginx pods. Drupal
+	// trusts the X-Forwarded-* headers of requests coming from them.
+	// +optional
+	ReverseProxyAddresses []string `json:"reverseProxyAddresses,omitempty"`
 	// IngressAnnotations for this Droplet site
 	// +optional
 	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+	// RoutingSpec specifies how traffic gets routed to the site
+	// +optional
+	RoutingSpec RoutingSpec `json:"routing,omitempty"`
+	// IngressSpec customizes the Ingress routing to the site
+	// +optional
+	IngressSpec *IngressSpec `json:"ingress,omitempty"`
+	// CacheSpec configures the cache backend used by Drupal. If not specified,
+	// Drupal caches in its database.
+	// +optional
+	CacheSpec *CacheSpec `json:"cache,omitempty"`
+	// SearchSpec configures the Solr server used by the search_api_solr
+	// module
+	// +optional
+	SearchSpec *SearchSpec `json:"search,omitempty"`
+	// MonitoringSpec configures the metrics exporters of the site
+	// +optional
+	MonitoringSpec *MonitoringSpec `json:"monitoring,omitempty"`
+}
+
+// MonitoringSpec defines how the site gets monitored by Prometheus
+type MonitoringSpec struct {
+	// Enabled adds the php-fpm and nginx exporter sidecars, and creates a
+	// PodMonitor when the Prometheus Operator is installed in the cluster
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// PHPFPMExporterImage is the image of the php-fpm exporter sidecar.
+	// Defaults to hipages/php-fpm_exporter:2
+	// +optional
+	PHPFPMExporterImage string `json:"phpFpmExporterImage,omitempty"`
+	// NginxExporterImage is the image of the nginx exporter sidecar.
+	// Defaults to nginx/nginx-prometheus-exporter:0.11.0
+	// +optional
+	NginxExporterImage string `json:"nginxExporterImage,omitempty"`
+	// Interval at which Prometheus scrapes the exporters. Defaults to the
+	// scrape interval of Prometheus
+	// +optional
+	Interval string `json:"interval,omitempty"`
+}
+
+// TLSSpec defines how the TLS certificate of the site gets issued
+type TLSSpec struct {
+	// IssuerRef references the cert-manager issuer of a certificate covering
+	// all domains of the site
+	// +optional
+	IssuerRef *CertIssuerRef `json:"issuerRef,omitempty"`
+}
+
+// CertIssuerRef references a cert-manager Issuer or ClusterIssuer
+type CertIssuerRef struct {
+	// Name of the issuer
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Kind of the issuer. Defaults to Issuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer. Defaults to cert-manager.io
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// RoutingSpec defines how traffic gets routed to the site
+type RoutingSpec struct {
+	// Mode is either ingress, gateway or none. Defaults to ingress
+	// +kubebuilder:validation:Enum=ingress;gateway;none
+	// +optional
+	Mode RoutingMode `json:"mode,omitempty"`
+	// GatewaySpec configures the HTTPRoute used in gateway mode
+	// +optional
+	GatewaySpec *GatewayRoutingSpec `json:"gateway,omitempty"`
+}
+
+// GatewayRoutingSpec is the desired spec for the HTTPRoute routing to the
+// site. The route gets one hostname per domain of the site.
+type GatewayRoutingSpec struct {
+	// ParentRef is the Gateway the HTTPRoute attaches to
+	ParentRef GatewayParentRef `json:"parentRef"`
+	// Filters applied to the requests routed to the site
+	// +optional
+	Filters []HTTPRouteFilter `json:"filters,omitempty"`
+}
+
+// GatewayParentRef references a Gateway
+type GatewayParentRef struct {
+	// Name of the Gateway
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Namespace of the Gateway. Defaults to the namespace of the site
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// SectionName is the name of the Gateway listener to attach to
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// HTTPRouteFilterType represents a type of HTTPRoute filter
+type HTTPRouteFilterType string
+
+const (
+	// RequestHeaderModifierFilter modifies the headers of requests
+	RequestHeaderModifierFilter HTTPRouteFilterType = "RequestHeaderModifier"
+	// ResponseHeaderModifierFilter modifies the headers of responses
+	ResponseHeaderModifierFilter HTTPRouteFilterType = "ResponseHeaderModifier"
+	// RequestRedirectFilter redirects requests
+	RequestRedirectFilter HTTPRouteFilterType = "RequestRedirect"
+)
+
+// HTTPRouteFilter is a filter of the Gateway API, serialized as such
+type HTTPRouteFilter struct {
+	// Type of the filter
+	// +kubebuilder:validation:Enum=RequestHeaderModifier;ResponseHeaderModifier;RequestRedirect
+	Type HTTPRouteFilterType `json:"type"`
+	// RequestHeaderModifier is set for RequestHeaderModifier filters
+	// +optional
+	RequestHeaderModifier *HTTPHeaderFilter `json:"requestHeaderModifier,omitempty"`
+	// ResponseHeaderModifier is set for ResponseHeaderModifier filters
+	// +optional
+	ResponseHeaderModifier *HTTPHeaderFilter `json:"responseHeaderModifier,omitempty"`
+	// RequestRedirect is set for RequestRedirect filters
+	// +optional
+	RequestRedirect *HTTPRequestRedirectFilter `json:"requestRedirect,omitempty"`
+}
+
+// HTTPHeaderFilter sets, adds or removes headers
+type HTTPHeaderFilter struct {
+	// +optional
+	Set []HTTPHeader `json:"set,omitempty"`
+	// +optional
+	Add []HTTPHeader `json:"add,omitempty"`
+	// +optional
+	Remove []string `json:"remove,omitempty"`
+}
+
+// HTTPHeader is a header name and value
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HTTPRequestRedirectFilter redirects requests
+type HTTPRequestRedirectFilter struct {
+	// +kubebuilder:validation:Enum=http;https
+	// +optional
+	Scheme *string `json:"scheme,omitempty"`
+	// +optional
+	Hostname *string `json:"hostname,omitempty"`
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+	// +kubebuilder:validation:Enum=301;302
+	// +optional
+	StatusCode *int `json:"statusCode,omitempty"`
+}
+
+// IngressSpec is the desired spec for the Ingress routing to the site
+type IngressSpec struct {
+	// Disabled skips creating the Ingress, same as routing mode none
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// IngressClassName is the name of the IngressClass handling the Ingress
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	// Path routed to the site. Defaults to /
+	// +optional
+	Path string `json:"path,omitempty"`
+	// PathType of the path routed to the site. Defaults to Prefix
+	// +kubebuilder:validation:Enum=Exact;Prefix;ImplementationSpecific
+	// +optional
+	PathType *networkingv1.PathType `json:"pathType,omitempty"`
+	// ExtraPaths get routed to their own backends, for every domain of the
+	// site
+	// +optional
+	ExtraPaths []IngressPath `json:"extraPaths,omitempty"`
+}
+
+// IngressPath routes a path to a backend
+type IngressPath struct {
+	// Path to route
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// PathType of the path. Defaults to Prefix
+	// +kubebuilder:validation:Enum=Exact;Prefix;ImplementationSpecific
+	// +optional
+	PathType *networkingv1.PathType `json:"pathType,omitempty"`
+	// Backend the path gets routed to
+	Backend networkingv1.IngressBackend `json:"backend"`
 }
 
 // DrupalSpec desired configuration for Drupal
@@ -69,7 +369,7 @@ type DrupalSpec struct {
 	// +optional
 	Tag string `json:"tag,omitempty"`
 	// ImagePullPolicy overrides DropletRuntime spec.imagePullPolicy
-	// +kubebuilder:validation:Enum=Always,IfNotPresent,Never
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
 	// +optional
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
 	// ImagePullSecrets defines additional secrets to use when pulling images
@@ -82,6 +382,16 @@ type DrupalSpec struct {
 	// container. If not specified, a media volume won't be mounted at all.
 	// +optional
 	MediaVolumeSpec *MediaVolumeSpec `json:"media,omitempty"`
+	// PrivateFilesVolumeSpec specifies how private files get mounted into the
+	// runtime container. If not specified, private files are written to the
+	// container's ephemeral filesystem. The MountPath defaults to
+	// /var/www/files_private. The credentials of a private files bucket are
+	// passed with a PRIVATE_FILES_ prefix. When both media and private files
+	// use the s3fs module, they must share the media bucket and private files
+	// are kept in the folder given by the prefix (s3fs-private by default).
+	// With flysystem, private files are served through the private:// scheme.
+	// +optional
+	PrivateFilesVolumeSpec *MediaVolumeSpec `json:"privateFiles,omitempty"`
 	// Volumes defines additional volumes to get injected into Drupal pods
 	// +optional
 	Volumes []corev1.Volume `json:"volumes,omitempty"`
@@ -100,6 +410,69 @@ type DrupalSpec struct {
 	// Database Backend to use. Defaults to latest
 	// +optional
 	DatabaseBackEnd string `json:"databaseBackend,omitempty"`
+	// PodSpec overrides resources, probes and scheduling of the Drupal pods
+	// +optional
+	PodSpec *PodSpec `json:"pod,omitempty"`
+	// AutoscalingSpec enables a HorizontalPodAutoscaler for the drupal pods.
+	// While autoscaling is enabled, replicas is only used as initial value.
+	// +optional
+	AutoscalingSpec *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// PodDisruptionBudgetSpec for the drupal pods. Defaults to at least one
+	// available pod if more than one replica is desired.
+	// +optional
+	PodDisruptionBudgetSpec *PodDisruptionBudgetSpec `json:"pdb,omitempty"`
+	// SettingsMode specifies how the generated settings.k8s.php gets loaded.
+	// In include mode, the settings.php of the site must include it. In
+	// override mode, a generic settings.php including it replaces the
+	// settings.php of the site. Defaults to include.
+	// +kubebuilder:validation:Enum=include;override
+	// +optional
+	SettingsMode SettingsMode `json:"settingsMode,omitempty"`
+	// SettingsSpec overrides values of the generated settings.php
+	// +optional
+	SettingsSpec *SettingsSpec `json:"settings,omitempty"`
+	// SettingsFrom includes settings.php snippets stored in ConfigMaps or
+	// Secrets, after the generated settings and the overrides
+	// +optional
+	SettingsFrom []SettingsFromSource `json:"settingsFrom,omitempty"`
+}
+
+// SettingsSpec defines overrides of the generated settings.php, which get
+// rendered after the generated settings
+type SettingsSpec struct {
+	// Settings overrides entries of the $settings array
+	// +optional
+	Settings []SettingOverride `json:"settings,omitempty"`
+	// Config overrides entries of the $config array
+	// +optional
+	Config []SettingOverride `json:"config,omitempty"`
+	// PHP lists raw PHP snippets, rendered as is after the overrides
+	// +optional
+	PHP []string `json:"php,omitempty"`
+}
+
+// SettingOverride sets a value in the $settings or $config array. The value
+// is NULL if none of string, bool or int is set.
+type SettingOverride struct {
+	// Keys is the path to the value in the array, eg. [system.performance,
+	// css, preprocess] for $config['system.performance']['css']['preprocess']
+	// +kubebuilder:validation:MinItems=1
+	Keys []string `json:"keys"`
+	// +optional
+	String *string `json:"string,omitempty"`
+	// +optional
+	Bool *bool `json:"bool,omitempty"`
+	// +optional
+	Int *int64 `json:"int,omitempty"`
+}
+
+// SettingsFromSource selects a settings.php snippet. Exactly one of
+// configMapKeyRef or secretKeyRef must be set.
+type SettingsFromSource struct {
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
 // NginxSpec desired configuration for Nginx
@@ -115,7 +488,7 @@ type NginxSpec struct {
 	// +optional
 	Tag string `json:"tag,omitempty"`
 	// ImagePullPolicy overrides DropletRuntime spec.imagePullPolicy
-	// +kubebuilder:validation:Enum=Always,IfNotPresent,Never
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
 	// +optional
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
 	// ImagePullSecrets defines additional secrets to use when pulling images
@@ -135,6 +508,269 @@ type NginxSpec struct {
 	// EnvFrom defines envFrom's which get passed into Nginx pods
 	// +optional
 	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// PodSpec overrides resources, probes and scheduling of the Nginx pods
+	// +optional
+	PodSpec *PodSpec `json:"pod,omitempty"`
+	// AutoscalingSpec enables a HorizontalPodAutoscaler for the nginx pods.
+	// While autoscaling is enabled, replicas is only used as initial value.
+	// +optional
+	AutoscalingSpec *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// PodDisruptionBudgetSpec for the nginx pods. Defaults to at least one
+	// available pod if more than one replica is desired.
+	// +optional
+	PodDisruptionBudgetSpec *PodDisruptionBudgetSpec `json:"pdb,omitempty"`
+	// CacheSpec enables caching of the pages rendered by Drupal
+	// +optional
+	CacheSpec *PageCacheSpec `json:"cache,omitempty"`
+	// Resolver is the DNS server nginx uses to look up the object storage
+	// host media files get proxied to. Defaults to the kube-dns Service of
+	// the cluster.
+	// +optional
+	Resolver string `json:"resolver,omitempty"`
+}
+
+// PageCacheSpec defines the cache of the pages rendered by Drupal. Pages get
+// cached by nginx, and optionally by Varnish in front of nginx.
+type PageCacheSpec struct {
+	// TTL of successful responses. Defaults to 10m
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// NotFoundTTL is the TTL of 404 responses. Defaults to 1m
+	// +optional
+	NotFoundTTL *metav1.Duration `json:"notFoundTTL,omitempty"`
+	// Size is the maximum size of the cache. Defaults to 1Gi
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+	// BypassCookies lists prefixes of the cookie names which bypass the
+	// cache, eg. the session cookies of logged-in users. Defaults to SESS,
+	// SSESS and NO_CACHE. Invalid cookie names fail the sync of the nginx
+	// configuration.
+	// +optional
+	BypassCookies []string `json:"bypassCookies,omitempty"`
+	// Purge enables purging of cached pages
+	// +optional
+	Purge *PageCachePurgeSpec `json:"purge,omitempty"`
+	// Varnish deploys Varnish in front of nginx. The ingress routes to
+	// Varnish while it is enabled.
+	// +optional
+	Varnish *VarnishSpec `json:"varnish,omitempty"`
+}
+
+// PageCachePurgeSpec defines who can purge cached pages. Nginx serves the
+// purge endpoint under /purge/<path>, which requires the ngx_cache_purge
+// module in the nginx image. Varnish accepts PURGE and BAN requests, as sent
+// by the varnish purger of Drupal's purge module.
+type PageCachePurgeSpec struct {
+	// AllowFrom lists the addresses or CIDRs allowed to purge. Defaults to
+	// the private networks. Invalid entries fail the sync of the nginx
+	// configuration. Purge requests which came through a proxy get a 403
+	// response, as their peer address isn't the address of the client: nginx
+	// denies any request carrying a X-Forwarded-For header, Varnish denies
+	// requests whose X-Forwarded-For header lists more than one address.
+	// +optional
+	AllowFrom []string `json:"allowFrom,omitempty"`
+}
+
+// VarnishSpec is the desired spec for the Varnish deployed in front of nginx
+type VarnishSpec struct {
+	// Image of Varnish. Defaults to varnish:6.0
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Number of desired Varnish pods. Defaults to 1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Resources of the Varnish container
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// CacheBackend represents a cache backend supported by Drupal
+type CacheBackend string
+
+const (
+	// RedisCacheBackend caches using the redis module
+	RedisCacheBackend CacheBackend = "redis"
+	// MemcacheCacheBackend caches using the memcache module
+	MemcacheCacheBackend CacheBackend = "memcache"
+)
+
+// DefaultPort returns the port the cache backend listens on by default
+func (backend CacheBackend) DefaultPort() int32 {
+	if backend == MemcacheCacheBackend {
+		return 11211
+	}
+	return 6379
+}
+
+// CacheSpec defines the cache backend of the site. The matching Drupal module
+// must be part of the site's code.
+type CacheSpec struct {
+	// Backend is the cache backend to use
+	// +kubebuilder:validation:Enum=redis;memcache
+	Backend CacheBackend `json:"backend"`
+	// Managed deploys the cache backend alongside the site. It is used if
+	// External is not specified.
+	// +optional
+	Managed *ManagedCacheSpec `json:"managed,omitempty"`
+	// External specifies an existing cache server to connect to
+	// +optional
+	External *ExternalCacheSpec `json:"external,omitempty"`
+}
+
+// ManagedCacheSpec is the desired spec for the cache backend deployed by the
+// operator
+type ManagedCacheSpec struct {
+	// Image of the cache server. Defaults to redis:5-alpine or
+	// memcached:1.5-alpine, depending on the backend
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Resources of the cache server container
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ExternalCacheSpec is the desired spec for connecting to an existing cache
+// server
+type ExternalCacheSpec struct {
+	// Host of the cache server
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+	// Port of the cache server. Defaults to the backend's default port
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// PasswordSecretRef is a secret holding the redis password under the
+	// CACHE_PASSWORD key
+	// +optional
+	PasswordSecretRef SecretRef `json:"passwordSecretRef,omitempty"`
+}
+
+// SearchSpec defines the Solr search backend of the site. The settings of the
+// search_api server get overridden to point at the Solr core.
+type SearchSpec struct {
+	// ServerID is the machine name of the search_api server to override.
+	// Defaults to solr
+	// +kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
+	// +optional
+	ServerID string `json:"serverID,omitempty"`
+	// Core is the name of the Solr core. Defaults to drupal. The sites of a
+	// multisite get a core of their own, named <core>_<site>, which has to
+	// exist on external Solr servers.
+	// +optional
+	Core string `json:"core,omitempty"`
+	// Managed deploys Solr alongside the site. It is used if External is not
+	// specified.
+	// +optional
+	Managed *ManagedSearchSpec `json:"managed,omitempty"`
+	// External specifies an existing Solr server to connect to
+	// +optional
+	External *ExternalSearchSpec `json:"external,omitempty"`
+	// ReindexToken triggers a job reindexing the site's content every time it
+	// changes
+	// +optional
+	ReindexToken string `json:"reindexToken,omitempty"`
+}
+
+// ManagedSearchSpec is the desired spec for the Solr server deployed by the
+// operator
+type ManagedSearchSpec struct {
+	// Image of the Solr server. Defaults to solr:7.7-slim
+	// +optional
+	Image string `json:"image,omitempty"`
+	// ConfigSetRef is a ConfigMap holding the files of the Solr config set
+	// generated by search_api_solr. Defaults to Solr's default config set.
+	// +optional
+	ConfigSetRef *corev1.LocalObjectReference `json:"configSetRef,omitempty"`
+	// PersistentVolumeClaim for the Solr data. If not specified, the data is
+	// stored in an emptyDir.
+	// +optional
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
+	// Resources of the Solr container
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ExternalSearchSpec is the desired spec for connecting to an existing Solr
+// server
+type ExternalSearchSpec struct {
+	// Scheme to connect with. Defaults to http
+	// +kubebuilder:validation:Enum=http;https
+	// +optional
+	Scheme string `json:"scheme,omitempty"`
+	// Host of the Solr server
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+	// Port of the Solr server. Defaults to 8983
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Path of the Solr server. Defaults to /
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// AutoscalingSpec defines the HorizontalPodAutoscaler of a tier
+type AutoscalingSpec struct {
+	// MinReplicas is the lower limit for the number of pods. Defaults to 1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit for the number of pods
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilizationPercentage is the target average CPU utilization
+	// relative to the requested CPU
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// TargetMemoryUtilizationPercentage is the target average memory
+	// utilization relative to the requested memory
+	// +optional
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+}
+
+// PodDisruptionBudgetSpec defines the PodDisruptionBudget of a tier. Only one
+// of MinAvailable and MaxUnavailable can be set.
+type PodDisruptionBudgetSpec struct {
+	// MinAvailable is the number or percentage of pods which must remain
+	// available during a disruption
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number or percentage of pods which can be
+	// unavailable during a disruption
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// PodSpec defines resources, health probes and scheduling for the pods of a
+// tier. Startup probes and topology spread constraints are not available in
+// the Kubernetes API version this operator is built against.
+type PodSpec struct {
+	// Resources for the main container of the tier
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// LivenessProbe overrides the default liveness probe of the main container
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+	// ReadinessProbe overrides the default readiness probe of the main
+	// container
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+	// StartupProbe of the main container, holding off the liveness and
+	// readiness probes until it succeeds
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+	// NodeSelector for scheduling the pods
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Affinity for scheduling the pods
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Tolerations for scheduling the pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// PriorityClassName of the pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// TopologySpreadConstraints for spreading the pods across failure domains
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
 }
 
 // GitVolumeSource is the desired spec for git code source
@@ -156,6 +792,27 @@ type GitVolumeSource struct {
 	// EmptyDir volume to use for git cloning.
 	// +optional
 	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	// Artifact specifies a shared volume into which the code gets built once
+	// per revision by a Job. When set, pods mount the built revision
+	// read-only instead of running their own git clone, and GitRef must be
+	// a full commit hash since a revision is never rebuilt.
+	// +optional
+	Artifact *CodeArtifactSpec `json:"artifact,omitempty"`
+}
+
+// CodeArtifactSpec is the desired spec for building the site's code once per
+// revision into a volume shared by all pods
+type CodeArtifactSpec struct {
+	// PersistentVolumeClaim for the shared code volume. The access modes
+	// default to ReadWriteMany.
+	PersistentVolumeClaim corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim"`
+	// RevisionHistoryLimit is the number of built revisions to keep in the
+	// shared volume, including the current one. At least 2 are kept so that
+	// pods of the previous revision keep working during a rollout. Defaults
+	// to 3.
+	// +kubebuilder:validation:Minimum=2
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
 }
 
 // S3VolumeSource is the desired spec for accessing media files over S3
@@ -166,6 +823,18 @@ type S3VolumeSource struct {
 	Bucket string `json:"bucket"`
 	// PathPrefix is the prefix for media files in bucket
 	PathPrefix string `json:"prefix,omitempty"`
+	// Region of the bucket. Defaults to us-east-1
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Endpoint is the URL of an S3 compatible object store to use instead of
+	// AWS (eg. https://minio.example.com)
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Module is the Drupal module used for accessing the bucket. Defaults to
+	// s3fs
+	// +kubebuilder:validation:Enum=s3fs;flysystem
+	// +optional
+	Module string `json:"module,omitempty"`
 	// Env variables for accessing S3 bucket. Taken into account are:
 	// ACCESS_KEY, SECRET_ACCESS_KEY
 	// +optional
@@ -190,12 +859,30 @@ type GCSVolumeSource struct {
 	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
 }
 
+// AzureBlobVolumeSource is the desired spec for accessing media files using
+// azure blob storage
+type AzureBlobVolumeSource struct {
+	// Account is the name of the storage account
+	// +kubebuilder:validation:MinLength=1
+	Account string `json:"account"`
+	// Container for storing media files
+	// +kubebuilder:validation:MinLength=1
+	Container string `json:"container"`
+	// PathPrefix is the prefix for media files in container
+	PathPrefix string `json:"prefix,omitempty"`
+	// CredentialsSecretRef is a secret holding the storage account access key
+	// under the AZURE_STORAGE_KEY key
+	// +optional
+	CredentialsSecretRef SecretRef `json:"credentialsSecretRef,omitempty"`
+}
+
 // CodeVolumeSpec is the desired spec for mounting code into the drupal
 // runtime container
 type CodeVolumeSpec struct {
 	// ReadOnly specifies if the volume should be mounted read-only inside the
 	// drupal runtime container
-	ReadOnly bool
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
 	// MountPath spechfies where should the code volume be mounted.
 	// Defaults to /var/www/html/modules/custom
 	// +optional
@@ -209,7 +896,9 @@ type CodeVolumeSpec struct {
 	// level of precedence over EmptyDir, HostPath and PersistentVolumeClaim
 	// +optional
 	GitDir *GitVolumeSource `json:"git,omitempty"`
-	// PersistentVolumeClaim to use if no GitDir is specified
+	// PersistentVolumeClaim to use if no GitDir is specified. Unless the
+	// topology is combined, it must allow ReadWriteMany or ReadOnlyMany
+	// access as the volume is mounted by the drupal and nginx pods.
 	// +optional
 	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
 	// HostPath to use if no PersistentVolumeClaim is specified
@@ -225,7 +914,17 @@ type CodeVolumeSpec struct {
 type MediaVolumeSpec struct {
 	// ReadOnly specifies if the volume should be mounted read-only inside the
 	// drupal runtime container
-	ReadOnly bool
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// MountPath specifies where should the media volume be mounted.
+	// Defaults to /var/www/html/sites/default/files. Nginx mounts it at the
+	// same path and serves it directly if it is under the /var/www/html
+	// webroot.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+	// SubPath specifies the path within the volume to mount
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
 	// S3VolumeSource specifies the S3 object storage configuration for media
 	// files. It has the highest level of precedence over EmptyDir, HostPath
 	// and PersistentVolumeClaim
@@ -236,8 +935,15 @@ type MediaVolumeSpec struct {
 	// over EmptyDir, HostPath and PersistentVolumeClaim
 	// +optional
 	GCSVolumeSource *GCSVolumeSource `json:"gcs,omitempty"`
-	// PersistentVolumeClaim to use if no S3VolumeSource or GCSVolumeSource are
-	// specified
+	// AzureBlobVolumeSource specifies the azure blob storage configuration for
+	// media files. It has the highest level of precedence over EmptyDir,
+	// HostPath and PersistentVolumeClaim
+	// +optional
+	AzureBlobVolumeSource *AzureBlobVolumeSource `json:"azureBlob,omitempty"`
+	// PersistentVolumeClaim to use if no S3VolumeSource, GCSVolumeSource or
+	// AzureBlobVolumeSource are specified. Unless the topology is combined,
+	// it must allow ReadWriteMany access as the volume is mounted by the
+	// drupal and nginx pods.
 	// +optional
 	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
 	// HostPath to use if no PersistentVolumeClaim is specified
@@ -248,6 +954,54 @@ type MediaVolumeSpec struct {
 	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
 }
 
+// IsObjectStorage returns true if files are stored in an object storage
+// bucket rather than on a mounted volume
+func (spec *MediaVolumeSpec) IsObjectStorage() bool {
+	return spec.S3VolumeSource != nil || spec.GCSVolumeSource != nil || spec.AzureBlobVolumeSource != nil
+}
+
+// DropletConditionType represents a condition of a Droplet
+type DropletConditionType string
+
+const (
+	// Ready is true while all desired drupal pods are ready
+	Ready DropletConditionType = "Ready"
+	// CertificateReady is true once the certificate issued for the site is
+	// ready
+	CertificateReady DropletConditionType = "CertificateReady"
+	// ConfigRenderFailed is true while a config file of the site fails to
+	// render from the spec
+	ConfigRenderFailed DropletConditionType = "ConfigRenderFailed"
+)
+
+// DropletCondition describes the state of a Droplet at a certain point
+type DropletCondition struct {
+	// Type of the condition
+	Type DropletConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// LastTransitionTime is the last time the condition changed its status
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a brief, machine readable reason for the last transition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message with details on the last
+	// transition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// AllDomains returns the domains of the default site followed by the
+// domains of all other sites
+func (spec *DropletSpec) AllDomains() []Domain {
+	out := append([]Domain{}, spec.Domains...)
+	for _, site := range spec.Sites {
+		out = append(out, site.Domains...)
+	}
+	return out
+}
+
 // DropletStatus defines the observed state of Droplet
 type DropletStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -256,6 +1010,19 @@ type DropletStatus struct {
 	// This is copied over from the deployment object
 	// +optional
 	Replicas int32 `json:"replicas,omitempty"`
+	// Total number of ready pods targeted by web deployment
+	// This is copied over from the deployment object
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Selector is the label selector of the web pods, used by the scale
+	// subresource
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// Conditions represent the latest observations of the droplet's state
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []DropletCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +genclient
@@ -264,7 +1031,8 @@ type DropletStatus struct {
 // Droplet is the Schema for the droplets API
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
-// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas
+// +kubebuilder:subresource:scale:specpath=.spec.drupal.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:resource:shortName=droplet
 type Droplet struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
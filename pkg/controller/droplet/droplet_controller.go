@@ -17,23 +17,38 @@ package droplet
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"time"
 
 	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	batchv1 "k8s.io/api/batch/v1"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
-	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
 	syncDrupal "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/drupal"
 	syncNginx "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/nginx"
 	"github.com/sylus/drupal-operator/pkg/internal/drupal"
@@ -57,7 +72,13 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileDroplet{Client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetRecorder(controllerName)}
+	return &ReconcileDroplet{
+		Client:    mgr.GetClient(),
+		dynamic:   dynamic.NewForConfigOrDie(mgr.GetConfig()),
+		discovery: discovery.NewDiscoveryClientForConfigOrDie(mgr.GetConfig()),
+		scheme:    mgr.GetScheme(),
+		recorder:  mgr.GetEventRecorderFor(controllerName),
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -74,14 +95,18 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	subresources := []runtime.Object{
+	subresources := []client.Object{
 		&appsv1.Deployment{},
+		&appsv1.StatefulSet{},
+		&batchv1.Job{},
 		&batchv1beta1.CronJob{},
 		&corev1.ConfigMap{},
 		&corev1.PersistentVolumeClaim{},
 		&corev1.Service{},
 		&corev1.Secret{},
-		&extv1beta1.Ingress{},
+		&networkingv1.Ingress{},
+		&autoscalingv2beta1.HorizontalPodAutoscaler{},
+		&policyv1beta1.PodDisruptionBudget{},
 	}
 
 	for _, subresource := range subresources {
@@ -102,29 +127,48 @@ var _ reconcile.Reconciler = &ReconcileDroplet{}
 // ReconcileDroplet reconciles a Droplet object
 type ReconcileDroplet struct {
 	client.Client
-	scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	// dynamic syncs objects of kinds which are not part of the scheme
+	dynamic dynamic.Interface
+	// discovery tells which of those kinds the cluster serves
+	discovery discovery.DiscoveryInterface
+	scheme    *runtime.Scheme
+	recorder  record.EventRecorder
 }
 
-// Reconcile reads that state of the cluster for a Droplet object and makes changes based on the state read
-// and what is in the Droplet.Spec
-// TODO(user): Modify this Reconcile function to implement your Controller logic.  The scaffolding writes
-// a Deployment as an example
-// Automatically generate RBAC rules to allow the Controller to read and write Deployments
-// +kubebuilder:rbac:groups=,resources=configmaps;secrets;services;persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// Automatically generate RBAC rules to allow the Controller to read and write the objects it owns
+// +kubebuilder:rbac:groups="",resources=configmaps;secrets;services;persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=cronjobs;jobs,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=extensions,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=podmonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=drupal.sylus.ca,resources=droplets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=drupal.sylus.ca,resources=droplets/status,verbs=get;update;patch
-func (r *ReconcileDroplet) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+
+// Reconcile reads that state of the cluster for a Droplet object and makes changes based on the state read
+// and what is in the Droplet.Spec
+// TODO(user): Modify this Reconcile function to implement your Controller logic.  The scaffolding writes
+// a Deployment as an example
+func (r *ReconcileDroplet) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
+	result, err := r.reconcileDroplet(ctx, request)
+	observeReconcile(request.NamespacedName, time.Since(start), err)
+	return result, err
+}
+
+func (r *ReconcileDroplet) reconcileDroplet(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	droplet := drupal.New(&drupalv1beta1.Droplet{})
-	err := r.Get(context.TODO(), request.NamespacedName, droplet.Unwrap())
+	err := r.Get(ctx, request.NamespacedName, droplet.Unwrap())
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			forgetDroplet(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -132,9 +176,16 @@ func (r *ReconcileDroplet) Reconcile(request reconcile.Request) (reconcile.Resul
 	}
 	r.scheme.Default(droplet.Unwrap())
 	droplet.SetDefaults()
+	if err = droplet.Validate(); err != nil {
+		// requeuing won't help until the spec gets fixed, which triggers a
+		// new reconcile
+		r.recorder.Event(droplet.Unwrap(), corev1.EventTypeWarning, "InvalidSpec", err.Error())
+		log.Error(err, "invalid droplet spec", "name", request.Name, "namespace", request.Namespace)
+		return reconcile.Result{}, nil
+	}
 
 	nginx := nginx.New(&drupalv1beta1.Droplet{})
-	err = r.Get(context.TODO(), request.NamespacedName, nginx.Unwrap())
+	err = r.Get(ctx, request.NamespacedName, nginx.Unwrap())
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
@@ -148,18 +199,140 @@ func (r *ReconcileDroplet) Reconcile(request reconcile.Request) (reconcile.Resul
 	nginx.SetDefaults()
 
 	secretSyncer := syncDrupal.NewSecretSyncer(droplet, r.Client, r.scheme)
+	deploymentSyncer := syncDrupal.NewDeploymentSyncer(droplet, secretSyncer.GetObject().(*corev1.Secret), r.Client, r.scheme)
 	syncers := []syncer.Interface{
 		secretSyncer,
 
 		syncDrupal.NewConfigMapSyncer(droplet, r.Client, r.scheme),
-		syncDrupal.NewDeploymentSyncer(droplet, secretSyncer.GetObject().(*corev1.Secret), r.Client, r.scheme),
+		deploymentSyncer,
 		syncDrupal.NewServiceSyncer(droplet, r.Client, r.scheme),
 		syncDrupal.NewDrupalCronSyncer(droplet, r.Client, r.scheme),
 
 		syncNginx.NewConfigMapSyncer(nginx, r.Client, r.scheme),
+	}
+
+	// syncers of objects which are no longer needed
+	var unused []syncer.Interface
+
+	nginxSyncers := []syncer.Interface{
 		syncNginx.NewDeploymentSyncer(nginx, r.Client, r.scheme),
 		syncNginx.NewServiceSyncer(nginx, r.Client, r.scheme),
-		syncNginx.NewIngressSyncer(nginx, r.Client, r.scheme),
+	}
+	if nginx.IsCombined() {
+		// nginx runs as a sidecar of the drupal pods
+		unused = append(unused, nginxSyncers...)
+	} else {
+		syncers = append(syncers, nginxSyncers...)
+	}
+
+	if nginx.HasIngress() {
+		syncers = append(syncers, syncNginx.NewIngressSyncer(nginx, r.Client, r.scheme))
+	} else {
+		unused = append(unused, syncNginx.NewIngressSyncer(nginx, r.Client, r.scheme))
+	}
+
+	// Certificates are not watched either, their readiness gets picked up on
+	// the next reconcile
+	certificateSyncer := syncNginx.NewCertificateSyncer(nginx, r.dynamic, r.scheme)
+	if nginx.HasCertificate() {
+		syncers = append(syncers, certificateSyncer)
+	} else {
+		unused = append(unused, certificateSyncer)
+	}
+
+	// HTTPRoutes are not watched, the Gateway API is not part of the scheme
+	if nginx.HasHTTPRoute() {
+		syncers = append(syncers, syncNginx.NewHTTPRouteSyncer(nginx, r.dynamic, r.scheme))
+	} else {
+		unused = append(unused, syncNginx.NewHTTPRouteSyncer(nginx, r.dynamic, r.scheme))
+	}
+
+	// PodMonitors are only created if the Prometheus Operator is installed,
+	// the exporters get scraped some other way otherwise
+	podMonitorSyncer := syncDrupal.NewPodMonitorSyncer(droplet, r.dynamic, r.scheme)
+	if droplet.HasMonitoring() {
+		var served bool
+		if served, err = r.serves(syncDrupal.PodMonitorResource); err != nil {
+			return reconcile.Result{}, err
+		}
+		if served {
+			syncers = append(syncers, podMonitorSyncer)
+		}
+	} else {
+		unused = append(unused, podMonitorSyncer)
+	}
+
+	varnishSyncers := []syncer.Interface{
+		syncNginx.NewVarnishConfigMapSyncer(nginx, r.Client, r.scheme),
+		syncNginx.NewVarnishDeploymentSyncer(nginx, r.Client, r.scheme),
+		syncNginx.NewVarnishServiceSyncer(nginx, r.Client, r.scheme),
+	}
+	if nginx.HasVarnish() {
+		syncers = append(syncers, varnishSyncers...)
+	} else {
+		unused = append(unused, varnishSyncers...)
+	}
+
+	if droplet.IsAutoscaled() {
+		syncers = append(syncers, syncDrupal.NewHPASyncer(droplet, r.Client, r.scheme))
+	} else {
+		unused = append(unused, syncDrupal.NewHPASyncer(droplet, r.Client, r.scheme))
+	}
+
+	if nginx.IsAutoscaled() && !nginx.IsCombined() {
+		syncers = append(syncers, syncNginx.NewHPASyncer(nginx, r.Client, r.scheme))
+	} else {
+		unused = append(unused, syncNginx.NewHPASyncer(nginx, r.Client, r.scheme))
+	}
+
+	cacheSyncers := []syncer.Interface{
+		syncDrupal.NewCacheDeploymentSyncer(droplet, r.Client, r.scheme),
+		syncDrupal.NewCacheServiceSyncer(droplet, r.Client, r.scheme),
+	}
+	if droplet.HasManagedCache() {
+		syncers = append(syncers, cacheSyncers...)
+	} else {
+		unused = append(unused, cacheSyncers...)
+	}
+
+	searchSyncers := []syncer.Interface{
+		syncDrupal.NewSearchStatefulSetSyncer(droplet, r.Client, r.scheme),
+		syncDrupal.NewSearchServiceSyncer(droplet, r.Client, r.scheme),
+	}
+	if droplet.HasManagedSearch() {
+		syncers = append(syncers, searchSyncers...)
+	} else {
+		unused = append(unused, searchSyncers...)
+	}
+
+	if droplet.Spec.SearchSpec != nil && len(droplet.Spec.SearchSpec.ReindexToken) > 0 {
+		syncers = append(syncers, syncDrupal.NewSearchReindexJobSyncer(droplet, r.Client, r.scheme))
+	}
+
+	for _, site := range droplet.Spec.Sites {
+		syncers = append(syncers,
+			syncDrupal.NewSiteCronSyncer(droplet, site, r.Client, r.scheme),
+			syncDrupal.NewSiteDBUpgradeJobSyncer(droplet, site, r.Client, r.scheme),
+		)
+		if len(site.InstallProfile) > 0 {
+			syncers = append(syncers, syncDrupal.NewSiteInstallJobSyncer(droplet, site, r.Client, r.scheme))
+		}
+	}
+
+	if droplet.HasPodDisruptionBudget() {
+		syncers = append(syncers, syncDrupal.NewPDBSyncer(droplet, r.Client, r.scheme))
+	} else {
+		unused = append(unused, syncDrupal.NewPDBSyncer(droplet, r.Client, r.scheme))
+	}
+
+	if nginx.HasPodDisruptionBudget() && !nginx.IsCombined() {
+		syncers = append(syncers, syncNginx.NewPDBSyncer(nginx, r.Client, r.scheme))
+	} else {
+		unused = append(unused, syncNginx.NewPDBSyncer(nginx, r.Client, r.scheme))
+	}
+
+	if err = r.cleanup(ctx, unused); err != nil {
+		return reconcile.Result{}, err
 	}
 
 	if droplet.Spec.Drupal.CodeVolumeSpec != nil && droplet.Spec.Drupal.CodeVolumeSpec.PersistentVolumeClaim != nil {
@@ -170,12 +343,264 @@ func (r *ReconcileDroplet) Reconcile(request reconcile.Request) (reconcile.Resul
 		syncers = append(syncers, syncDrupal.NewMediaPVCSyncer(droplet, r.Client, r.scheme))
 	}
 
-	return reconcile.Result{}, r.sync(syncers)
+	if droplet.Spec.Drupal.PrivateFilesVolumeSpec != nil && droplet.Spec.Drupal.PrivateFilesVolumeSpec.PersistentVolumeClaim != nil {
+		syncers = append(syncers, syncDrupal.NewPrivateFilesPVCSyncer(droplet, r.Client, r.scheme))
+	}
+
+	if droplet.HasCodeArtifact() {
+		syncers = append(syncers, syncDrupal.NewCodeArtifactPVCSyncer(droplet, r.Client, r.scheme))
+		syncers = append(syncers, syncDrupal.NewCodeBuildJobSyncer(droplet, r.Client, r.scheme))
+	}
+
+	if err = r.sync(ctx, request.NamespacedName, syncers); err != nil {
+		if common.IsRenderError(err) {
+			status := *droplet.Status.DeepCopy()
+			setCondition(&status, drupalv1beta1.DropletCondition{
+				Type:    drupalv1beta1.ConfigRenderFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "TemplateError",
+				Message: err.Error(),
+			})
+			observeConditions(request.NamespacedName, status)
+			if statusErr := r.updateStatus(ctx, droplet, status, nil); statusErr != nil {
+				log.Error(statusErr, "unable to update droplet status")
+			}
+		}
+		return reconcile.Result{}, err
+	}
+
+	status := *droplet.Status.DeepCopy()
+	removeCondition(&status, drupalv1beta1.ConfigRenderFailed)
+	if nginx.HasCertificate() {
+		certificate := certificateSyncer.GetObject().(*unstructured.Unstructured)
+		setCondition(&status, syncNginx.CertificateCondition(certificate))
+	} else {
+		removeCondition(&status, drupalv1beta1.CertificateReady)
+	}
+
+	deployment := deploymentSyncer.GetObject().(*appsv1.Deployment)
+	setCondition(&status, readyCondition(deployment))
+	observeConditions(request.NamespacedName, status)
+
+	jobList := &batchv1.JobList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(droplet.Namespace),
+		client.MatchingLabels{
+			"app.kubernetes.io/instance":   droplet.Name,
+			"app.kubernetes.io/managed-by": common.ControllerLabels["app.kubernetes.io/managed-by"],
+		},
+	}
+	if err = r.List(ctx, jobList, listOptions...); err != nil {
+		return reconcile.Result{}, err
+	}
+	observeJobs(request.NamespacedName, jobList)
+	if err = r.cleanupCodeBuilds(ctx, droplet, jobList); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	cronJobList := &batchv1beta1.CronJobList{}
+	if err = r.List(ctx, cronJobList, listOptions...); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err = r.cleanupSites(ctx, droplet, cronJobList, jobList); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.updateStatus(ctx, droplet, status, deployment)
 }
 
-func (r *ReconcileDroplet) sync(syncers []syncer.Interface) error {
+// readyCondition reports whether all desired drupal pods are ready
+func readyCondition(deployment *appsv1.Deployment) drupalv1beta1.DropletCondition {
+	var desired int32 = 1
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	out := drupalv1beta1.DropletCondition{
+		Type:    drupalv1beta1.Ready,
+		Status:  corev1.ConditionTrue,
+		Reason:  "ReplicasReady",
+		Message: fmt.Sprintf("%d of %d replicas ready", deployment.Status.ReadyReplicas, desired),
+	}
+	if deployment.Status.ReadyReplicas < desired || deployment.Status.ReadyReplicas == 0 {
+		out.Status = corev1.ConditionFalse
+		out.Reason = "ReplicasNotReady"
+	}
+	return out
+}
+
+// updateStatus copies the replica counts of the drupal Deployment into the
+// droplet status, backing the scale subresource, and persists the status if
+// it changed. The replica counts are left alone if the Deployment is nil, eg.
+// because it did not get synced.
+func (r *ReconcileDroplet) updateStatus(ctx context.Context, droplet *drupal.Drupal, status drupalv1beta1.DropletStatus, deployment *appsv1.Deployment) error {
+	if deployment != nil {
+		status.Replicas = deployment.Status.Replicas
+		status.ReadyReplicas = deployment.Status.ReadyReplicas
+	}
+	status.Selector = labels.SelectorFromSet(droplet.PodLabels()).String()
+
+	if reflect.DeepEqual(status, droplet.Status) {
+		return nil
+	}
+
+	droplet.Status = status
+	return r.Status().Update(ctx, droplet.Unwrap())
+}
+
+// setCondition adds or updates a condition, the transition time only moves
+// when the condition's status changes
+func setCondition(status *drupalv1beta1.DropletStatus, condition drupalv1beta1.DropletCondition) {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type != condition.Type {
+			continue
+		}
+		condition.LastTransitionTime = status.Conditions[i].LastTransitionTime
+		if status.Conditions[i].Status != condition.Status {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		status.Conditions[i] = condition
+		return
+	}
+	condition.LastTransitionTime = metav1.Now()
+	status.Conditions = append(status.Conditions, condition)
+}
+
+// removeCondition removes the condition of the given type
+func removeCondition(status *drupalv1beta1.DropletStatus, conditionType drupalv1beta1.DropletConditionType) {
+	conditions := []drupalv1beta1.DropletCondition{}
+	for _, c := range status.Conditions {
+		if c.Type != conditionType {
+			conditions = append(conditions, c)
+		}
+	}
+	if len(conditions) != len(status.Conditions) {
+		status.Conditions = conditions
+	}
+}
+
+// cleanup deletes the objects of syncers which are no longer needed. Objects
+// not controlled by the syncer's owner are left alone.
+func (r *ReconcileDroplet) cleanup(ctx context.Context, syncers []syncer.Interface) error {
 	for _, s := range syncers {
-		if err := syncer.Sync(context.TODO(), s, r.recorder); err != nil {
+		if u, ok := s.(*syncer.UnstructuredSyncer); ok {
+			if err := u.Delete(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		obj, ok := s.GetObject().(client.Object)
+		if !ok {
+			continue
+		}
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		ownerMeta, err := meta.Accessor(s.GetOwner())
+		if err != nil {
+			return err
+		}
+
+		key := types.NamespacedName{Name: objMeta.GetName(), Namespace: objMeta.GetNamespace()}
+		if err = r.Get(ctx, key, obj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !metav1.IsControlledBy(objMeta, ownerMeta) {
+			continue
+		}
+
+		if err = r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "unable to delete object", "name", key.Name, "namespace", key.Namespace)
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupCodeBuilds deletes the finished code build Jobs of revisions other
+// than the current one
+func (r *ReconcileDroplet) cleanupCodeBuilds(ctx context.Context, droplet *drupal.Drupal, jobs *batchv1.JobList) error {
+	current := ""
+	if droplet.HasCodeArtifact() {
+		current = droplet.ComponentName(drupal.DrupalCodeBuild)
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Labels["app.kubernetes.io/component"] != drupal.DrupalCodeBuild.Name() || job.Name == current {
+			continue
+		}
+		if jobStatus(job) == "active" || !metav1.IsControlledBy(job, droplet.Unwrap()) {
+			continue
+		}
+
+		err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "unable to delete code build job", "name", job.Name, "namespace", job.Namespace)
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupSites deletes the CronJobs and Jobs of sites which were removed from
+// spec.sites
+func (r *ReconcileDroplet) cleanupSites(ctx context.Context, droplet *drupal.Drupal, cronJobs *batchv1beta1.CronJobList, jobs *batchv1.JobList) error {
+	sites := map[string]bool{}
+	for _, site := range droplet.Spec.Sites {
+		sites[site.Name] = true
+	}
+
+	objs := []client.Object{}
+	for i := range cronJobs.Items {
+		objs = append(objs, &cronJobs.Items[i])
+	}
+	for i := range jobs.Items {
+		objs = append(objs, &jobs.Items[i])
+	}
+
+	for _, obj := range objs {
+		site, ok := obj.GetLabels()["drupal.sylus.ca/site"]
+		if !ok || sites[site] || !metav1.IsControlledBy(obj, droplet.Unwrap()) {
+			continue
+		}
+
+		err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "unable to delete object of removed site", "name", obj.GetName(), "namespace", obj.GetNamespace())
+			return err
+		}
+	}
+	return nil
+}
+
+// serves returns true if the cluster serves the given resource, eg. because
+// the CRD defining it is installed
+func (r *ReconcileDroplet) serves(resource schema.GroupVersionResource) (bool, error) {
+	resources, err := r.discovery.ServerResourcesForGroupVersion(resource.GroupVersion().String())
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	for _, served := range resources.APIResources {
+		if served.Name == resource.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *ReconcileDroplet) sync(ctx context.Context, key types.NamespacedName, syncers []syncer.Interface) error {
+	for _, s := range syncers {
+		result, err := syncer.SyncWithResult(ctx, s, r.recorder)
+		observeSync(key, result, err)
+		if err != nil {
 			log.Error(err, "unable to reconcile with object ")
 			return err
 		}
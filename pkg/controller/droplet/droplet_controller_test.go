@@ -16,19 +16,27 @@ limitations under the License.
 package droplet
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/onsi/gomega"
 	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
-	"golang.org/x/net/context"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
 )
 
 var c client.Client
@@ -54,7 +62,7 @@ func TestReconcile(t *testing.T) {
 	stopMgr, mgrStopped := StartTestManager(mgr, g)
 
 	defer func() {
-		close(stopMgr)
+		stopMgr()
 		mgrStopped.Wait()
 	}()
 
@@ -85,3 +93,57 @@ func TestReconcile(t *testing.T) {
 	g.Expect(c.Delete(context.TODO(), deploy)).To(gomega.Succeed())
 
 }
+
+func TestCleanupSites(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	droplet := drupal.New(&drupalv1beta1.Droplet{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", UID: "foo-uid"},
+		Spec: drupalv1beta1.DropletSpec{
+			Sites: []drupalv1beta1.SiteSpec{
+				{Name: "kept", Domains: []drupalv1beta1.Domain{"kept.example.com"}},
+			},
+		},
+	})
+
+	siteObjectMeta := func(name, site string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				"app.kubernetes.io/instance": "foo",
+				"drupal.sylus.ca/site":       site,
+			},
+		}
+	}
+	keptCron := &batchv1beta1.CronJob{ObjectMeta: siteObjectMeta("foo-kept-drupal-cron", "kept")}
+	removedCron := &batchv1beta1.CronJob{ObjectMeta: siteObjectMeta("foo-removed-drupal-cron", "removed")}
+	keptJob := &batchv1.Job{ObjectMeta: siteObjectMeta("foo-kept-site-install", "kept")}
+	removedJob := &batchv1.Job{ObjectMeta: siteObjectMeta("foo-removed-site-install", "removed")}
+	// objects of other controllers are left alone
+	foreignJob := &batchv1.Job{ObjectMeta: siteObjectMeta("foo-removed-backup", "removed")}
+	for _, obj := range []client.Object{keptCron, removedCron, keptJob, removedJob} {
+		g.Expect(controllerutil.SetControllerReference(droplet.Unwrap(), obj, scheme)).To(gomega.Succeed())
+	}
+
+	r := &ReconcileDroplet{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(keptCron, removedCron, keptJob, removedJob, foreignJob).Build(),
+		scheme: scheme,
+	}
+	cronJobs := &batchv1beta1.CronJobList{Items: []batchv1beta1.CronJob{*keptCron, *removedCron}}
+	jobs := &batchv1.JobList{Items: []batchv1.Job{*keptJob, *removedJob, *foreignJob}}
+	g.Expect(r.cleanupSites(context.TODO(), droplet, cronJobs, jobs)).To(gomega.Succeed())
+
+	for _, obj := range []client.Object{keptCron, keptJob, foreignJob} {
+		g.Expect(r.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj)).To(gomega.Succeed())
+	}
+	for _, obj := range []client.Object{removedCron, removedJob} {
+		err := r.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj)
+		g.Expect(apierrors.IsNotFound(err)).To(gomega.BeTrue())
+	}
+}
@@ -17,6 +17,8 @@ package common
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"text/template"
 )
 
@@ -25,17 +27,45 @@ var ControllerLabels = map[string]string{
 	"app.kubernetes.io/managed-by": "drupal-operator.sylus.ca",
 }
 
-// GenerateConfig returns rendered template against given interface
-func GenerateConfig(TemplateInput interface{}, configmapTemplate string) *string {
+// templateFuncs are the functions available to config templates
+var templateFuncs = template.FuncMap{
+	"php": PHPString,
+}
+
+// PHPString returns s as a single quoted PHP string literal
+func PHPString(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `'`, `\'`, -1)
+	return "'" + s + "'"
+}
+
+// RenderError is returned when a config template fails to render
+type RenderError struct {
+	// Name of the rendered file
+	Name string
+	Err  error
+}
+
+func (e *RenderError) Error() string {
+	return fmt.Sprintf("unable to render %s: %s", e.Name, e.Err)
+}
+
+// IsRenderError returns true if err is a RenderError
+func IsRenderError(err error) bool {
+	_, ok := err.(*RenderError)
+	return ok
+}
+
+// GenerateConfig returns the named template rendered against given interface
+func GenerateConfig(name string, TemplateInput interface{}, configmapTemplate string) (string, error) {
 	output := new(bytes.Buffer)
-	tpl, err := template.New("config").Delims("[[", "]]").Parse(configmapTemplate)
+	tpl, err := template.New(name).Delims("[[", "]]").Funcs(templateFuncs).Parse(configmapTemplate)
 	if err != nil {
-		return nil
+		return "", &RenderError{Name: name, Err: err}
 	}
 	err = tpl.Execute(output, TemplateInput)
 	if err != nil {
-		return nil
+		return "", &RenderError{Name: name, Err: err}
 	}
-	outputString := output.String()
-	return &outputString
+	return output.String(), nil
 }
@@ -0,0 +1,99 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var (
+	oneReplica int32 = 1
+)
+
+// NewHPASyncer returns a new sync.Interface for reconciling the named
+// HorizontalPodAutoscaler of a Deployment. The specPath of the autoscaling
+// spec is used in errors.
+func NewHPASyncer(droplet *drupalv1beta1.Droplet, name string, objLabels labels.Set, deployment string, spec *drupalv1beta1.AutoscalingSpec, specPath string, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	obj := &autoscalingv2beta1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("HPA", droplet, obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*autoscalingv2beta1.HorizontalPodAutoscaler)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), ControllerLabels)
+
+		if spec == nil {
+			return fmt.Errorf("%s is not defined", specPath)
+		}
+
+		out.Spec = HorizontalPodAutoscalerSpec(deployment, spec)
+
+		return nil
+	})
+}
+
+// HorizontalPodAutoscalerSpec returns the HorizontalPodAutoscaler spec for
+// scaling the named Deployment
+func HorizontalPodAutoscalerSpec(deployment string, spec *drupalv1beta1.AutoscalingSpec) autoscalingv2beta1.HorizontalPodAutoscalerSpec {
+	out := autoscalingv2beta1.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2beta1.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       deployment,
+		},
+		MinReplicas: spec.MinReplicas,
+		MaxReplicas: spec.MaxReplicas,
+	}
+
+	if out.MinReplicas == nil {
+		out.MinReplicas = &oneReplica
+	}
+
+	if spec.TargetCPUUtilizationPercentage != nil {
+		out.Metrics = append(out.Metrics, autoscalingv2beta1.MetricSpec{
+			Type: autoscalingv2beta1.ResourceMetricSourceType,
+			Resource: &autoscalingv2beta1.ResourceMetricSource{
+				Name:                     corev1.ResourceCPU,
+				TargetAverageUtilization: spec.TargetCPUUtilizationPercentage,
+			},
+		})
+	}
+
+	if spec.TargetMemoryUtilizationPercentage != nil {
+		out.Metrics = append(out.Metrics, autoscalingv2beta1.MetricSpec{
+			Type: autoscalingv2beta1.ResourceMetricSourceType,
+			Resource: &autoscalingv2beta1.ResourceMetricSource{
+				Name:                     corev1.ResourceMemory,
+				TargetAverageUtilization: spec.TargetMemoryUtilizationPercentage,
+			},
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,80 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+// stableLabels are the pod labels which don't change over the lifetime of a
+// droplet, unlike eg. app.kubernetes.io/version
+var stableLabels = []string{"app.kubernetes.io/instance", "app.kubernetes.io/component"}
+
+// NewPDBSyncer returns a new sync.Interface for reconciling the named
+// PodDisruptionBudget of the pods with the given labels
+func NewPDBSyncer(droplet *drupalv1beta1.Droplet, name string, objLabels, podLabels labels.Set, spec *drupalv1beta1.PodDisruptionBudgetSpec, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	obj := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("PDB", droplet, obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*policyv1beta1.PodDisruptionBudget)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), ControllerLabels)
+
+		// PodDisruptionBudgets are mutable since kubernetes 1.15
+		out.Spec = PodDisruptionBudgetSpec(podLabels, spec)
+
+		return nil
+	})
+}
+
+// PodDisruptionBudgetSpec returns the PodDisruptionBudget spec for the pods
+// with the given labels, selected by their stable labels only. If spec is nil
+// at least one pod is kept available.
+func PodDisruptionBudgetSpec(podLabels labels.Set, spec *drupalv1beta1.PodDisruptionBudgetSpec) policyv1beta1.PodDisruptionBudgetSpec {
+	selector := labels.Set{}
+	for _, key := range stableLabels {
+		if value, ok := podLabels[key]; ok {
+			selector[key] = value
+		}
+	}
+
+	out := policyv1beta1.PodDisruptionBudgetSpec{
+		Selector: metav1.SetAsLabelSelector(selector),
+	}
+
+	if spec == nil || (spec.MinAvailable == nil && spec.MaxUnavailable == nil) {
+		minAvailable := intstr.FromInt(1)
+		out.MinAvailable = &minAvailable
+		return out
+	}
+
+	out.MinAvailable = spec.MinAvailable
+	out.MaxUnavailable = spec.MaxUnavailable
+
+	return out
+}
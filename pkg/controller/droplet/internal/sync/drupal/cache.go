@@ -0,0 +1,141 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/imdario/mergo"
+
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/mergo/transformers"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var (
+	oneReplica int32 = 1
+)
+
+// NewCacheDeploymentSyncer returns a new sync.Interface for reconciling the
+// managed cache Deployment
+func NewCacheDeploymentSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(drupal.DrupalCache)
+
+	obj := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.ComponentName(drupal.DrupalCache),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("CacheDeployment", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*appsv1.Deployment)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		if !droplet.HasManagedCache() {
+			return fmt.Errorf(".spec.cache.managed is not defined")
+		}
+
+		selector := metav1.SetAsLabelSelector(droplet.CachePodLabels())
+		if !reflect.DeepEqual(selector, out.Spec.Selector) {
+			if out.ObjectMeta.CreationTimestamp.IsZero() {
+				out.Spec.Selector = selector
+			} else {
+				return fmt.Errorf("deployment selector is immutable")
+			}
+		}
+
+		out.Spec.Template.ObjectMeta.Labels = droplet.CachePodLabels()
+
+		_, port := droplet.CacheAddress()
+		spec := corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  string(droplet.Spec.CacheSpec.Backend),
+					Image: droplet.Spec.CacheSpec.Managed.Image,
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "cache",
+							ContainerPort: port,
+						},
+					},
+					Resources: droplet.Spec.CacheSpec.Managed.Resources,
+				},
+			},
+		}
+
+		err := mergo.Merge(&out.Spec.Template.Spec, spec, mergo.WithTransformers(transformers.PodSpec))
+		if err != nil {
+			return err
+		}
+
+		out.Spec.Replicas = &oneReplica
+
+		return nil
+	})
+}
+
+// NewCacheServiceSyncer returns a new sync.Interface for reconciling the
+// managed cache Service
+func NewCacheServiceSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(drupal.DrupalCache)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.ComponentName(drupal.DrupalCache),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("CacheService", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*corev1.Service)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		if !droplet.HasManagedCache() {
+			return fmt.Errorf(".spec.cache.managed is not defined")
+		}
+
+		selector := droplet.CachePodLabels()
+		if !labels.Equals(selector, out.Spec.Selector) {
+			if out.ObjectMeta.CreationTimestamp.IsZero() {
+				out.Spec.Selector = selector
+			} else {
+				return fmt.Errorf("service selector is immutable")
+			}
+		}
+
+		if len(out.Spec.Ports) != 1 {
+			out.Spec.Ports = make([]corev1.ServicePort, 1)
+		}
+
+		_, port := droplet.CacheAddress()
+		out.Spec.Ports[0].Name = "cache"
+		out.Spec.Ports[0].Port = port
+		out.Spec.Ports[0].TargetPort = intstr.FromInt(int(port))
+
+		return nil
+	})
+}
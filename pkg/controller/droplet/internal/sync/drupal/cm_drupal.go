@@ -17,6 +17,9 @@ package sync
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
 	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
 	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/templates"
 	"github.com/sylus/drupal-operator/pkg/internal/drupal"
@@ -39,6 +43,279 @@ type Settings struct {
 	Port      string
 	Namespace string
 	Driver    string
+	Media     *MediaSettings
+	// PrivateFiles is set if private files are stored in object storage
+	PrivateFiles *MediaSettings
+	Cache        *CacheSettings
+	Search       *SearchSettings
+	// TrustedHostPatterns are regular expressions matching the domains of
+	// the site
+	TrustedHostPatterns   []string
+	ReverseProxyAddresses []string
+	// FilesPath is the public files directory, left to the drupal default
+	// if empty
+	FilesPath string
+	// PrivateFilesPath is the private files directory, left unset if the
+	// private:// scheme is provided by flysystem
+	PrivateFilesPath string
+	// Overrides are PHP assignments overriding $settings and $config values
+	Overrides []string
+	// PHP are raw PHP snippets
+	PHP []string
+	// SettingsFrom are the paths of the included settings.php snippets
+	SettingsFrom []string
+}
+
+// settingsOverrides renders the overrides of spec.drupal.settings as PHP
+// assignments
+func settingsOverrides(spec *drupalv1beta1.SettingsSpec) (out []string) {
+	if spec == nil {
+		return nil
+	}
+	for _, o := range spec.Settings {
+		out = append(out, phpAssignment("$settings", o))
+	}
+	for _, o := range spec.Config {
+		out = append(out, phpAssignment("$config", o))
+	}
+	return out
+}
+
+// phpAssignment renders an override as an assignment to an entry of the
+// variable, with keys and value escaped as PHP literals
+func phpAssignment(variable string, o drupalv1beta1.SettingOverride) string {
+	lvalue := variable
+	for _, key := range o.Keys {
+		lvalue += "[" + common.PHPString(key) + "]"
+	}
+
+	value := "NULL"
+	switch {
+	case o.String != nil:
+		value = common.PHPString(*o.String)
+	case o.Bool != nil && *o.Bool:
+		value = "TRUE"
+	case o.Bool != nil:
+		value = "FALSE"
+	case o.Int != nil:
+		value = strconv.FormatInt(*o.Int, 10)
+	}
+
+	return fmt.Sprintf("%s = %s;", lvalue, value)
+}
+
+func settingsFrom(droplet *drupal.Drupal) (out []string) {
+	for i := range droplet.Spec.Drupal.SettingsFrom {
+		out = append(out, drupal.SettingsFromPath(i))
+	}
+	return out
+}
+
+func trustedHostPatterns(domains []drupalv1beta1.Domain) []string {
+	out := []string{}
+	for _, d := range domains {
+		out = append(out, fmt.Sprintf("^%s$", regexp.QuoteMeta(string(d))))
+	}
+	return out
+}
+
+// SitesSettings spec for the sites.php file of a multisite
+type SitesSettings struct {
+	Entries []SiteEntry
+}
+
+// SiteEntry maps a domain to a site directory
+type SiteEntry struct {
+	Domain    string
+	Directory string
+}
+
+func newSitesSettings(droplet *drupal.Drupal) SitesSettings {
+	out := SitesSettings{}
+	for _, site := range droplet.Spec.Sites {
+		for _, d := range site.Domains {
+			out.Entries = append(out.Entries, SiteEntry{Domain: string(d), Directory: site.Name})
+		}
+	}
+	return out
+}
+
+// siteSettings returns the settings of a site of a multisite, derived from
+// the settings of the default site
+func siteSettings(droplet *drupal.Drupal, settings Settings, site drupalv1beta1.SiteSpec) Settings {
+	settings.Name = site.Database
+	settings.FilesPath = site.FilesPath
+	if len(settings.PrivateFilesPath) > 0 {
+		settings.PrivateFilesPath = path.Join(settings.PrivateFilesPath, site.Name)
+	}
+	settings.TrustedHostPatterns = trustedHostPatterns(site.Domains)
+	settings.Media = siteMediaSettings(settings.Media, site.Name)
+	settings.PrivateFiles = siteMediaSettings(settings.PrivateFiles, site.Name)
+	if settings.Cache != nil {
+		cache := *settings.Cache
+		cache.Prefix = droplet.CachePrefix(site.Name)
+		settings.Cache = &cache
+	}
+	if settings.Search != nil {
+		search := *settings.Search
+		search.Core = droplet.SearchCore(site.Name)
+		settings.Search = &search
+	}
+	return settings
+}
+
+// siteMediaSettings keeps the files of a site under its own prefix of the
+// bucket
+func siteMediaSettings(media *MediaSettings, site string) *MediaSettings {
+	if media == nil {
+		return nil
+	}
+	out := *media
+	out.Prefix = path.Join(media.Prefix, site)
+	return &out
+}
+
+// SearchSettings spec for the Solr server of the search_api server
+type SearchSettings struct {
+	ServerID string
+	Scheme   string
+	Host     string
+	Port     int32
+	Path     string
+	Core     string
+}
+
+func newSearchSettings(droplet *drupal.Drupal) *SearchSettings {
+	if droplet.Spec.SearchSpec == nil {
+		return nil
+	}
+
+	scheme, host, port, path := droplet.SearchAddress()
+	return &SearchSettings{
+		ServerID: droplet.Spec.SearchSpec.ServerID,
+		Scheme:   scheme,
+		Host:     host,
+		Port:     port,
+		Path:     path,
+		Core:     droplet.SearchCore(""),
+	}
+}
+
+// CacheSettings spec for the cache backend
+type CacheSettings struct {
+	// Backend is the cache backend (redis or memcache)
+	Backend string
+	Host    string
+	Port    int32
+	// Prefix of the cache keys of the site
+	Prefix string
+	// HasPassword is set if the password is passed in CACHE_PASSWORD
+	HasPassword bool
+}
+
+func newCacheSettings(droplet *drupal.Drupal) *CacheSettings {
+	if droplet.Spec.CacheSpec == nil {
+		return nil
+	}
+
+	host, port := droplet.CacheAddress()
+	out := &CacheSettings{
+		Backend: string(droplet.Spec.CacheSpec.Backend),
+		Host:    host,
+		Port:    port,
+		Prefix:  droplet.CachePrefix(""),
+	}
+	if droplet.Spec.CacheSpec.External != nil {
+		out.HasPassword = len(droplet.Spec.CacheSpec.External.PasswordSecretRef) > 0
+	}
+
+	return out
+}
+
+// MediaSettings spec for media files stored in object storage
+type MediaSettings struct {
+	// Module is the Drupal module accessing the bucket (s3fs or flysystem)
+	Module string
+	// Driver is the flysystem driver (s3, gcs or azure)
+	Driver string
+	// Account is the azure storage account
+	Account  string
+	Bucket   string
+	Prefix   string
+	Region   string
+	Endpoint string
+	// EnvPrefix prefixes the environment variables holding the credentials
+	EnvPrefix string
+	// Folder is the folder of the bucket holding private files with s3fs
+	Folder string
+	// SharedBucket is set if private files are kept by s3fs in the bucket
+	// configured for public files
+	SharedBucket bool
+}
+
+func newMediaSettings(media *drupalv1beta1.MediaVolumeSpec) *MediaSettings {
+	if media == nil {
+		return nil
+	}
+
+	switch {
+	case media.S3VolumeSource != nil:
+		return &MediaSettings{
+			Module:   media.S3VolumeSource.Module,
+			Driver:   "s3",
+			Bucket:   media.S3VolumeSource.Bucket,
+			Prefix:   media.S3VolumeSource.PathPrefix,
+			Region:   media.S3VolumeSource.Region,
+			Endpoint: media.S3VolumeSource.Endpoint,
+		}
+	case media.GCSVolumeSource != nil:
+		return &MediaSettings{
+			Module: "flysystem",
+			Driver: "gcs",
+			Bucket: media.GCSVolumeSource.Bucket,
+			Prefix: media.GCSVolumeSource.PathPrefix,
+		}
+	case media.AzureBlobVolumeSource != nil:
+		return &MediaSettings{
+			Module:  "flysystem",
+			Driver:  "azure",
+			Account: media.AzureBlobVolumeSource.Account,
+			Bucket:  media.AzureBlobVolumeSource.Container,
+			Prefix:  media.AzureBlobVolumeSource.PathPrefix,
+		}
+	}
+
+	return nil
+}
+
+// newPrivateFilesSettings returns the settings for private files stored in
+// object storage. The s3fs module keeps them in a folder of its bucket.
+func newPrivateFilesSettings(droplet *drupal.Drupal) *MediaSettings {
+	out := newMediaSettings(droplet.Spec.Drupal.PrivateFilesVolumeSpec)
+	if out == nil {
+		return nil
+	}
+
+	out.EnvPrefix = drupal.PrivateFilesEnvPrefix
+	if out.Module == "s3fs" {
+		out.Folder = out.Prefix
+		if len(out.Folder) == 0 {
+			out.Folder = "s3fs-private"
+		}
+		out.Prefix = ""
+		out.SharedBucket = droplet.PrivateFilesShareMediaBucket()
+	}
+
+	return out
+}
+
+// privateFilesPath returns the private files directory, which is left unset
+// if flysystem provides the private:// scheme
+func privateFilesPath(droplet *drupal.Drupal, privateFiles *MediaSettings) string {
+	if privateFiles != nil && privateFiles.Module != "s3fs" {
+		return ""
+	}
+	return droplet.PrivateFilesMountPath()
 }
 
 // NewConfigMapSyncer returns a new sync.Interface for reconciling web Deployment
@@ -46,16 +323,29 @@ func NewConfigMapSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime
 	objLabels := droplet.ComponentLabels(drupal.DrupalConfigMap)
 
 	databaseBackend, databasePort := droplet.DataBaseBackend()
+	privateFiles := newPrivateFilesSettings(droplet)
 	templateInput := Settings{
-		Name:      "drupal",
-		User:      "root",
-		Pass:      "my-super-secret-pass",
-		Host:      fmt.Sprintf("%s-%s", droplet.ComponentName(drupal.DrupalConfigMap), databaseBackend),
-		Port:      databasePort,
-		Namespace: fmt.Sprintf("%s%s", "Drupal\\Core\\Database\\Driver\\", databaseBackend),
-		Driver:    databaseBackend,
+		Name:         "drupal",
+		User:         "root",
+		Pass:         "my-super-secret-pass",
+		Host:         fmt.Sprintf("%s-%s", droplet.ComponentName(drupal.DrupalConfigMap), databaseBackend),
+		Port:         databasePort,
+		Namespace:    fmt.Sprintf("%s%s", "Drupal\\Core\\Database\\Driver\\", databaseBackend),
+		Driver:       databaseBackend,
+		Media:        newMediaSettings(droplet.Spec.Drupal.MediaVolumeSpec),
+		PrivateFiles: privateFiles,
+		Cache:        newCacheSettings(droplet),
+		Search:       newSearchSettings(droplet),
+
+		TrustedHostPatterns:   trustedHostPatterns(droplet.Spec.Domains),
+		ReverseProxyAddresses: droplet.Spec.ReverseProxyAddresses,
+		PrivateFilesPath:      privateFilesPath(droplet, privateFiles),
+		Overrides:             settingsOverrides(droplet.Spec.Drupal.SettingsSpec),
+		SettingsFrom:          settingsFrom(droplet),
+	}
+	if droplet.Spec.Drupal.SettingsSpec != nil {
+		templateInput.PHP = droplet.Spec.Drupal.SettingsSpec.PHP
 	}
-	configMap := common.GenerateConfig(templateInput, templates.ConfigMapSettings)
 
 	obj := &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
@@ -66,15 +356,54 @@ func NewConfigMapSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime
 			Name:      droplet.ComponentName(drupal.DrupalConfigMap),
 			Namespace: droplet.Namespace,
 		},
-		Data: map[string]string{
-			"d8.settings.php": *configMap,
-		},
 	}
 
 	return syncer.NewObjectSyncer("ConfigMap", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
 		out := existing.(*corev1.ConfigMap)
 		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
 
+		data, err := settingsFiles(droplet, templateInput)
+		if err != nil {
+			return err
+		}
+		out.Data = data
+
 		return nil
 	})
 }
+
+// settingsFiles renders the settings of the default site and of all other
+// sites, keyed by ConfigMap entry. The php-fpm pool config enabling the
+// status page is added when monitoring is enabled.
+func settingsFiles(droplet *drupal.Drupal, templateInput Settings) (map[string]string, error) {
+	settings, err := common.GenerateConfig("settings.k8s.php", templateInput, templates.ConfigMapSettingsK8s)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]string{
+		"settings.k8s.php": settings,
+	}
+	if droplet.OverridesSettings() {
+		data["d8.settings.php"] = templates.ConfigMapSettings
+	}
+	if droplet.HasMonitoring() {
+		data[drupal.FPMStatusConfigKey] = drupal.FPMStatusConfig
+	}
+
+	if len(droplet.Spec.Sites) > 0 {
+		data["sites.php"], err = common.GenerateConfig("sites.php", newSitesSettings(droplet), templates.ConfigMapSites)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, site := range droplet.Spec.Sites {
+		key := drupal.SiteSettingsFile(site.Name)
+		data[key], err = common.GenerateConfig(key, siteSettings(droplet, templateInput, site), templates.ConfigMapSettingsK8s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
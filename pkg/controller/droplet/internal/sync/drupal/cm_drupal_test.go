@@ -0,0 +1,227 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	"github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/drupal"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var _ = ginkgo.Describe("ConfigMap syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *drupal.Drupal
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = drupal.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com"},
+			},
+		})
+	})
+
+	configMapData := func() map[string]string {
+		s := NewConfigMapSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := &corev1.ConfigMap{}
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		return out.Data
+	}
+
+	settingsPHP := func() string {
+		return configMapData()["settings.k8s.php"]
+	}
+
+	ginkgo.It("renders the generic settings.php only in override mode", func() {
+		gomega.Expect(configMapData()).NotTo(gomega.HaveKey("d8.settings.php"))
+
+		droplet.Spec.Drupal.SettingsMode = drupalv1beta1.OverrideSettingsMode
+		gomega.Expect(configMapData()["d8.settings.php"]).To(
+			gomega.ContainSubstring("include __DIR__ . '/settings.k8s.php';"))
+	})
+
+	ginkgo.It("renders typed overrides", func() {
+		preprocess := false
+		ttl := int64(300)
+		mail := "admin@example.com"
+		droplet.Spec.Drupal.SettingsSpec = &drupalv1beta1.SettingsSpec{
+			Settings: []drupalv1beta1.SettingOverride{
+				{Keys: []string{"hash_salt"}},
+			},
+			Config: []drupalv1beta1.SettingOverride{
+				{Keys: []string{"system.performance", "css", "preprocess"}, Bool: &preprocess},
+				{Keys: []string{"system.performance", "cache", "page", "max_age"}, Int: &ttl},
+				{Keys: []string{"system.site", "mail"}, String: &mail},
+			},
+		}
+
+		out := settingsPHP()
+		gomega.Expect(out).To(gomega.ContainSubstring("$settings['hash_salt'] = NULL;\n"))
+		gomega.Expect(out).To(gomega.ContainSubstring("$config['system.performance']['css']['preprocess'] = FALSE;\n"))
+		gomega.Expect(out).To(gomega.ContainSubstring("$config['system.performance']['cache']['page']['max_age'] = 300;\n"))
+		gomega.Expect(out).To(gomega.ContainSubstring("$config['system.site']['mail'] = 'admin@example.com';\n"))
+	})
+
+	ginkgo.It("escapes keys and values", func() {
+		value := `it's \'; phpinfo(); //`
+		droplet.Spec.Drupal.SettingsSpec = &drupalv1beta1.SettingsSpec{
+			Settings: []drupalv1beta1.SettingOverride{
+				{Keys: []string{"a']['b"}, String: &value},
+			},
+		}
+
+		gomega.Expect(settingsPHP()).To(gomega.ContainSubstring(
+			`$settings['a\'][\'b'] = 'it\'s \\\'; phpinfo(); //';`))
+	})
+
+	ginkgo.It("renders raw PHP and includes settingsFrom snippets", func() {
+		droplet.Spec.Drupal.SettingsSpec = &drupalv1beta1.SettingsSpec{
+			PHP: []string{"$settings['update_free_access'] = TRUE;"},
+		}
+		droplet.Spec.Drupal.SettingsFrom = []drupalv1beta1.SettingsFromSource{
+			{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "site-settings"},
+				Key:                  "settings.php",
+			}},
+		}
+
+		out := settingsPHP()
+		gomega.Expect(out).To(gomega.ContainSubstring("\n$settings['update_free_access'] = TRUE;\n"))
+		gomega.Expect(out).To(gomega.ContainSubstring(
+			"if (file_exists('/var/run/sylus.ca/settings/0/settings.php')) {\n  include '/var/run/sylus.ca/settings/0/settings.php';\n}"))
+	})
+
+	table.DescribeTable("private files settings",
+		func(media, private *drupalv1beta1.MediaVolumeSpec, present, absent []string) {
+			droplet.Spec.Drupal.MediaVolumeSpec = media
+			droplet.Spec.Drupal.PrivateFilesVolumeSpec = private
+			gomega.Expect(droplet.Validate()).To(gomega.Succeed())
+
+			out := settingsPHP()
+			for _, s := range present {
+				gomega.Expect(out).To(gomega.ContainSubstring(s))
+			}
+			for _, s := range absent {
+				gomega.Expect(out).NotTo(gomega.ContainSubstring(s))
+			}
+		},
+		table.Entry("volume at the default path", nil,
+			&drupalv1beta1.MediaVolumeSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			[]string{"$settings['file_private_path'] =  '/var/www/files_private';"},
+			[]string{"s3fs.use_s3_for_private", "$settings['flysystem']['private']"}),
+		table.Entry("volume at a custom path", nil,
+			&drupalv1beta1.MediaVolumeSpec{MountPath: "/mnt/private", EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			[]string{"$settings['file_private_path'] =  '/mnt/private';"}, nil),
+		table.Entry("s3fs sharing the media bucket",
+			&drupalv1beta1.MediaVolumeSpec{S3VolumeSource: &drupalv1beta1.S3VolumeSource{
+				Bucket: "media", Region: "ca-central-1", PathPrefix: "public", Module: "s3fs"}},
+			&drupalv1beta1.MediaVolumeSpec{S3VolumeSource: &drupalv1beta1.S3VolumeSource{
+				Bucket: "media", Region: "ca-central-1", PathPrefix: "private", Module: "s3fs"}},
+			[]string{
+				"$config['s3fs.settings']['root_folder'] = 'public';",
+				"$config['s3fs.settings']['private_folder'] = 'private';",
+				"$settings['s3fs.use_s3_for_private'] = TRUE;",
+			},
+			[]string{
+				"$config['s3fs.settings']['root_folder'] = '';",
+				"getenv('PRIVATE_FILES_AWS_ACCESS_KEY_ID')",
+			}),
+		table.Entry("s3fs for private files only", nil,
+			&drupalv1beta1.MediaVolumeSpec{S3VolumeSource: &drupalv1beta1.S3VolumeSource{
+				Bucket: "private", Region: "ca-central-1", Module: "s3fs"}},
+			[]string{
+				"$config['s3fs.settings']['bucket'] = 'private';",
+				"$config['s3fs.settings']['private_folder'] = 's3fs-private';",
+				"$settings['s3fs.access_key'] = getenv('PRIVATE_FILES_AWS_ACCESS_KEY_ID');",
+				"$settings['file_private_path'] =  '/var/www/files_private';",
+			},
+			[]string{"s3fs.use_s3_for_public"}),
+		table.Entry("flysystem providing the private scheme", nil,
+			&drupalv1beta1.MediaVolumeSpec{GCSVolumeSource: &drupalv1beta1.GCSVolumeSource{
+				Bucket: "private", PathPrefix: "files"}},
+			[]string{
+				"$settings['flysystem']['private'] = array(",
+				"'keyFilePath' => getenv('PRIVATE_FILES_GOOGLE_APPLICATION_CREDENTIALS'),",
+				"'public' => FALSE,",
+			},
+			[]string{"$settings['file_private_path']"}),
+	)
+
+	ginkgo.It("rejects s3fs private files in another bucket than media", func() {
+		droplet.Spec.Drupal.MediaVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+			S3VolumeSource: &drupalv1beta1.S3VolumeSource{Bucket: "media", Module: "s3fs"},
+		}
+		droplet.Spec.Drupal.PrivateFilesVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+			S3VolumeSource: &drupalv1beta1.S3VolumeSource{Bucket: "private", Module: "s3fs"},
+		}
+		gomega.Expect(droplet.Validate()).NotTo(gomega.Succeed())
+
+		droplet.Spec.Drupal.PrivateFilesVolumeSpec.S3VolumeSource.Module = "flysystem"
+		gomega.Expect(droplet.Validate()).To(gomega.Succeed())
+	})
+
+	ginkgo.Describe("multisite", func() {
+		ginkgo.BeforeEach(func() {
+			droplet.Spec.Sites = []drupalv1beta1.SiteSpec{{
+				Name:    "blog",
+				Domains: []drupalv1beta1.Domain{"blog.example.com"},
+			}}
+		})
+
+		siteSettingsPHP := func() string {
+			data := configMapData()
+			gomega.Expect(data).To(gomega.HaveKey(drupal.SiteSettingsFile("blog")))
+			return data[drupal.SiteSettingsFile("blog")]
+		}
+
+		table.DescribeTable("cache prefix",
+			func(backend drupalv1beta1.CacheBackend, setting string) {
+				droplet.Spec.CacheSpec = &drupalv1beta1.CacheSpec{Backend: backend}
+				drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+
+				gomega.Expect(settingsPHP()).To(gomega.ContainSubstring(setting + " = 'site';"))
+				gomega.Expect(siteSettingsPHP()).To(gomega.ContainSubstring(setting + " = 'site_blog';"))
+			},
+			table.Entry("redis", drupalv1beta1.RedisCacheBackend, "$settings['cache_prefix']"),
+			table.Entry("memcache", drupalv1beta1.MemcacheCacheBackend, "$settings['memcache']['key_prefix']"),
+		)
+
+		ginkgo.It("points every site at a Solr core of its own", func() {
+			droplet.Spec.SearchSpec = &drupalv1beta1.SearchSpec{}
+			drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+
+			gomega.Expect(settingsPHP()).To(gomega.ContainSubstring("$solr_connector['core'] = 'drupal';"))
+			gomega.Expect(siteSettingsPHP()).To(gomega.ContainSubstring("$solr_connector['core'] = 'drupal_blog';"))
+		})
+	})
+})
@@ -0,0 +1,130 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	"github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/drupal"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+const commitHash = "0123456789abcdef0123456789abcdef01234567"
+
+var _ = ginkgo.Describe("Code build Job syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *drupal.Drupal
+	)
+
+	newDroplet := func(ref string) *drupal.Drupal {
+		d := drupal.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Drupal: drupalv1beta1.DrupalSpec{
+					CodeVolumeSpec: &drupalv1beta1.CodeVolumeSpec{
+						GitDir: &drupalv1beta1.GitVolumeSource{
+							Repository: "https://github.com/example/site.git",
+							GitRef:     ref,
+							Artifact:   &drupalv1beta1.CodeArtifactSpec{},
+						},
+					},
+				},
+			},
+		})
+		d.SetDefaults()
+		return d
+	}
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = newDroplet(commitHash)
+	})
+
+	table.DescribeTable("code artifact validation",
+		func(ref string, valid bool) {
+			d := newDroplet(ref)
+			if valid {
+				gomega.Expect(d.ValidateCodeArtifact()).To(gomega.Succeed())
+			} else {
+				gomega.Expect(d.ValidateCodeArtifact()).NotTo(gomega.Succeed())
+			}
+		},
+		table.Entry("accepts commit hashes", commitHash, true),
+		table.Entry("rejects branches", "master", false),
+		table.Entry("rejects tags", "v1.2.0", false),
+		table.Entry("rejects abbreviated hashes", "0123456", false),
+		table.Entry("rejects uppercase hashes", "0123456789ABCDEF0123456789ABCDEF01234567", false),
+		table.Entry("rejects empty references", "", false),
+	)
+
+	table.DescribeTable("code revision",
+		func(ref, revision string) {
+			gomega.Expect(newDroplet(ref).CodeRevision()).To(gomega.Equal(revision))
+		},
+		table.Entry("shortens commit hashes", commitHash, "0123456789ab"),
+		table.Entry("shortens other commit hashes", "fedcba9876543210fedcba9876543210fedcba98", "fedcba987654"),
+	)
+
+	ginkgo.It("accepts any reference without an artifact", func() {
+		droplet.Spec.Drupal.CodeVolumeSpec.GitDir.GitRef = "master"
+		droplet.Spec.Drupal.CodeVolumeSpec.GitDir.Artifact = nil
+		gomega.Expect(droplet.ValidateCodeArtifact()).To(gomega.Succeed())
+	})
+
+	ginkgo.It("names the Job after the code revision", func() {
+		s := NewCodeBuildJobSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := s.GetObject().(*batchv1.Job)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+
+		gomega.Expect(out.Name).To(gomega.Equal("site-code-build-for-0123456789ab"))
+		gomega.Expect(out.Labels).To(gomega.HaveKeyWithValue("app.kubernetes.io/component", "code-build"))
+		gomega.Expect(out.Labels).To(gomega.HaveKeyWithValue("drupal.sylus.ca/code-revision", "0123456789ab"))
+		gomega.Expect(out.Spec.Template.Spec.Containers[0].Env).To(gomega.ContainElement(
+			corev1.EnvVar{Name: "CODE_REVISION", Value: "0123456789ab"}))
+		gomega.Expect(out.Spec.Template.Spec.Containers[0].Env).To(gomega.ContainElement(
+			corev1.EnvVar{Name: "REVISION_HISTORY_LIMIT", Value: "3"}))
+	})
+
+	ginkgo.It("gives the artifact volume its own component", func() {
+		gomega.Expect(drupal.DrupalCodeArtifactPVC.Name()).To(gomega.Equal("code-artifact"))
+		gomega.Expect(droplet.ComponentLabels(drupal.DrupalCodeArtifactPVC)).NotTo(
+			gomega.Equal(droplet.ComponentLabels(drupal.DrupalCodePVC)))
+	})
+
+	ginkgo.It("requires an artifact for git code in the split topology", func() {
+		droplet.Spec.Drupal.CodeVolumeSpec.GitDir.Artifact = nil
+		droplet.Spec.Topology = drupalv1beta1.SplitTopology
+		gomega.Expect(droplet.Validate()).NotTo(gomega.Succeed())
+
+		droplet.Spec.Topology = drupalv1beta1.CombinedTopology
+		gomega.Expect(droplet.Validate()).To(gomega.Succeed())
+	})
+})
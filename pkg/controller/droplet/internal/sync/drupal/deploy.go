@@ -30,6 +30,7 @@ import (
 
 	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
 	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
 	"github.com/sylus/drupal-operator/pkg/util/mergo/transformers"
 	"github.com/sylus/drupal-operator/pkg/util/syncer"
 )
@@ -50,6 +51,11 @@ func NewDeploymentSyncer(droplet *drupal.Drupal, secret *corev1.Secret, c client
 		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
 
 		template := droplet.PodTemplateSpec()
+		if droplet.IsCombined() {
+			sidecar := nginx.New(droplet.Unwrap().DeepCopy())
+			sidecar.SetDefaults()
+			addNginxSidecar(&template, sidecar)
+		}
 
 		if len(template.Annotations) == 0 {
 			template.Annotations = make(map[string]string)
@@ -72,10 +78,55 @@ func NewDeploymentSyncer(droplet *drupal.Drupal, secret *corev1.Secret, c client
 			return err
 		}
 
-		if droplet.Spec.Drupal.Replicas != nil {
+		// the HorizontalPodAutoscaler owns the replica count, only set the
+		// initial value
+		if droplet.Spec.Drupal.Replicas != nil && (!droplet.IsAutoscaled() || out.Spec.Replicas == nil) {
 			out.Spec.Replicas = droplet.Spec.Drupal.Replicas
 		}
 
 		return nil
 	})
 }
+
+// addNginxSidecar adds the nginx container, and its exporter when monitoring
+// is enabled, to the drupal pod template. Volumes and init containers the
+// drupal pod already has are shared with nginx.
+func addNginxSidecar(template *corev1.PodTemplateSpec, sidecar *nginx.Nginx) {
+	template.Spec.ImagePullSecrets = append(template.Spec.ImagePullSecrets, sidecar.Spec.Nginx.ImagePullSecrets...)
+	template.Spec.Containers = append(template.Spec.Containers, sidecar.Container())
+	if sidecar.HasMonitoring() {
+		template.Spec.Containers = append(template.Spec.Containers, sidecar.ExporterContainer())
+	}
+
+	volumes := map[string]bool{}
+	for _, volume := range template.Spec.Volumes {
+		volumes[volume.Name] = true
+	}
+	for _, volume := range sidecar.Volumes() {
+		if !volumes[volume.Name] {
+			template.Spec.Volumes = append(template.Spec.Volumes, volume)
+		}
+	}
+
+	initContainers := map[string]bool{}
+	for _, container := range template.Spec.InitContainers {
+		initContainers[container.Name] = true
+	}
+	for _, container := range sidecar.InitContainers() {
+		if !initContainers[container.Name] {
+			template.Spec.InitContainers = append(template.Spec.InitContainers, container)
+		}
+	}
+}
+
+// NewHPASyncer returns a new sync.Interface for reconciling Drupal HorizontalPodAutoscaler
+func NewHPASyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	return common.NewHPASyncer(droplet.Unwrap(), droplet.ComponentName(drupal.DrupalHPA), droplet.ComponentLabels(drupal.DrupalHPA),
+		droplet.ComponentName(drupal.DrupalDeployment), droplet.Spec.Drupal.AutoscalingSpec, ".spec.drupal.autoscaling", c, scheme)
+}
+
+// NewPDBSyncer returns a new sync.Interface for reconciling Drupal PodDisruptionBudget
+func NewPDBSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	return common.NewPDBSyncer(droplet.Unwrap(), droplet.ComponentName(drupal.DrupalPDB), droplet.ComponentLabels(drupal.DrupalPDB),
+		droplet.PodLabels(), droplet.Spec.Drupal.PodDisruptionBudgetSpec, c, scheme)
+}
@@ -0,0 +1,332 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	"github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/drupal"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var _ = ginkgo.Describe("Deployment syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *drupal.Drupal
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = drupal.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com"},
+			},
+		})
+		droplet.SetDefaults()
+	})
+
+	sync := func() *appsv1.Deployment {
+		s := NewDeploymentSyncer(droplet, &corev1.Secret{}, nil, scheme).(*syncer.ObjectSyncer)
+		out := s.GetObject().(*appsv1.Deployment)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		return out
+	}
+
+	// env returns the value of each environment variable of the drupal
+	// container, the last definition of a variable winning
+	env := func(deployment *appsv1.Deployment) map[string]string {
+		out := map[string]string{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			out[e.Name] = e.Value
+		}
+		return out
+	}
+
+	var (
+		s3 = &drupalv1beta1.S3VolumeSource{
+			Bucket: "media-s3",
+			Env:    []corev1.EnvVar{{Name: "AWS_ACCESS_KEY_ID", Value: "s3-key"}},
+		}
+		gcs = &drupalv1beta1.GCSVolumeSource{
+			Bucket: "media-gcs",
+			Env:    []corev1.EnvVar{{Name: "GOOGLE_CREDENTIALS", Value: "gcs-key"}},
+		}
+		azure = &drupalv1beta1.AzureBlobVolumeSource{
+			Account:   "account",
+			Container: "media-azure",
+		}
+	)
+
+	table.DescribeTable("object storage env precedence",
+		func(media drupalv1beta1.MediaVolumeSpec, bucket string, present, absent []string) {
+			droplet.Spec.Drupal.MediaVolumeSpec = &media
+			out := env(sync())
+
+			gomega.Expect(out).To(gomega.HaveKeyWithValue("MEDIA_BUCKET", bucket))
+			for _, name := range present {
+				gomega.Expect(out).To(gomega.HaveKey(name))
+			}
+			for _, name := range absent {
+				gomega.Expect(out).NotTo(gomega.HaveKey(name))
+			}
+		},
+		table.Entry("s3 only",
+			drupalv1beta1.MediaVolumeSpec{S3VolumeSource: s3},
+			"s3://media-s3", []string{"AWS_ACCESS_KEY_ID"}, []string{"GOOGLE_CREDENTIALS", "AZURE_STORAGE_ACCOUNT"}),
+		table.Entry("azure only",
+			drupalv1beta1.MediaVolumeSpec{AzureBlobVolumeSource: azure},
+			"https://account.blob.core.windows.net/media-azure", []string{"AZURE_STORAGE_ACCOUNT"}, nil),
+		table.Entry("s3 wins over gcs and azure",
+			drupalv1beta1.MediaVolumeSpec{S3VolumeSource: s3, GCSVolumeSource: gcs, AzureBlobVolumeSource: azure},
+			"s3://media-s3", []string{"AWS_ACCESS_KEY_ID"}, []string{"GOOGLE_CREDENTIALS", "AZURE_STORAGE_ACCOUNT"}),
+		table.Entry("gcs wins over azure",
+			drupalv1beta1.MediaVolumeSpec{GCSVolumeSource: gcs, AzureBlobVolumeSource: azure},
+			"gs://media-gcs", []string{"GOOGLE_CREDENTIALS"}, []string{"AZURE_STORAGE_ACCOUNT"}),
+	)
+
+	ginkgo.It("prefixes the credentials of the private files bucket", func() {
+		droplet.Spec.Drupal.MediaVolumeSpec = &drupalv1beta1.MediaVolumeSpec{S3VolumeSource: s3}
+		droplet.Spec.Drupal.PrivateFilesVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+			S3VolumeSource: &drupalv1beta1.S3VolumeSource{
+				Bucket: "private",
+				Env:    []corev1.EnvVar{{Name: "AWS_ACCESS_KEY_ID", Value: "private-key"}},
+			},
+		}
+		out := env(sync())
+
+		gomega.Expect(out).To(gomega.HaveKeyWithValue("AWS_ACCESS_KEY_ID", "s3-key"))
+		gomega.Expect(out).To(gomega.HaveKeyWithValue("PRIVATE_FILES_AWS_ACCESS_KEY_ID", "private-key"))
+		gomega.Expect(out).To(gomega.HaveKeyWithValue("PRIVATE_FILES_BUCKET", "s3://private"))
+	})
+
+	ginkgo.It("mounts the private files volume as specified", func() {
+		droplet.Spec.Drupal.PrivateFilesVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+			MountPath: "/mnt/private",
+			SubPath:   "site",
+			ReadOnly:  true,
+			EmptyDir:  &corev1.EmptyDirVolumeSource{},
+		}
+
+		gomega.Expect(sync().Spec.Template.Spec.Containers[0].VolumeMounts).To(gomega.ContainElement(corev1.VolumeMount{
+			Name:      "private-files",
+			MountPath: "/mnt/private",
+			SubPath:   "site",
+			ReadOnly:  true,
+		}))
+	})
+
+	table.DescribeTable("shared volume access modes",
+		func(topology drupalv1beta1.Topology, mode corev1.PersistentVolumeAccessMode, valid bool) {
+			droplet.Spec.Topology = topology
+			droplet.Spec.Drupal.MediaVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{mode},
+				},
+			}
+			if valid {
+				gomega.Expect(droplet.Validate()).To(gomega.Succeed())
+			} else {
+				gomega.Expect(droplet.Validate()).NotTo(gomega.Succeed())
+			}
+		},
+		table.Entry("split with ReadWriteMany", drupalv1beta1.SplitTopology, corev1.ReadWriteMany, true),
+		table.Entry("split with ReadWriteOnce", drupalv1beta1.SplitTopology, corev1.ReadWriteOnce, false),
+		table.Entry("combined with ReadWriteOnce", drupalv1beta1.CombinedTopology, corev1.ReadWriteOnce, true),
+	)
+
+	ginkgo.It("removes tolerations dropped from the spec", func() {
+		droplet.Spec.Drupal.PodSpec = &drupalv1beta1.PodSpec{
+			Tolerations: []corev1.Toleration{
+				{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "drupal", Effect: corev1.TaintEffectNoSchedule},
+			},
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+			},
+			StartupProbe: &corev1.Probe{FailureThreshold: 30},
+		}
+		out := sync()
+		gomega.Expect(out.Spec.Template.Spec.Tolerations).To(gomega.HaveLen(1))
+		gomega.Expect(out.Spec.Template.Spec.TopologySpreadConstraints).To(gomega.HaveLen(1))
+		gomega.Expect(out.Spec.Template.Spec.Containers[0].StartupProbe).NotTo(gomega.BeNil())
+
+		droplet.Spec.Drupal.PodSpec = nil
+		s := NewDeploymentSyncer(droplet, &corev1.Secret{}, nil, scheme).(*syncer.ObjectSyncer)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		gomega.Expect(out.Spec.Template.Spec.Tolerations).To(gomega.BeEmpty())
+		gomega.Expect(out.Spec.Template.Spec.TopologySpreadConstraints).To(gomega.BeEmpty())
+		gomega.Expect(out.Spec.Template.Spec.Containers[0].StartupProbe).To(gomega.BeNil())
+	})
+})
+
+var _ = ginkgo.Describe("PDB syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *drupal.Drupal
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = drupal.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com"},
+			},
+		})
+		droplet.SetDefaults()
+	})
+
+	sync := func(out *policyv1beta1.PodDisruptionBudget) *policyv1beta1.PodDisruptionBudget {
+		s := NewPDBSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		return out
+	}
+
+	var (
+		one     = intstr.FromInt(1)
+		two     = intstr.FromInt(2)
+		percent = intstr.FromString("25%")
+	)
+
+	table.DescribeTable("budget",
+		func(spec *drupalv1beta1.PodDisruptionBudgetSpec, minAvailable, maxUnavailable *intstr.IntOrString) {
+			droplet.Spec.Drupal.PodDisruptionBudgetSpec = spec
+			out := sync(&policyv1beta1.PodDisruptionBudget{})
+			gomega.Expect(out.Spec.MinAvailable).To(gomega.Equal(minAvailable))
+			gomega.Expect(out.Spec.MaxUnavailable).To(gomega.Equal(maxUnavailable))
+		},
+		table.Entry("defaults to one available pod", nil, &one, nil),
+		table.Entry("defaults to one available pod when empty", &drupalv1beta1.PodDisruptionBudgetSpec{}, &one, nil),
+		table.Entry("minAvailable", &drupalv1beta1.PodDisruptionBudgetSpec{MinAvailable: &two}, &two, nil),
+		table.Entry("maxUnavailable", &drupalv1beta1.PodDisruptionBudgetSpec{MaxUnavailable: &percent}, nil, &percent),
+	)
+
+	ginkgo.It("selects the drupal pods by their stable labels", func() {
+		out := sync(&policyv1beta1.PodDisruptionBudget{})
+		gomega.Expect(out.Spec.Selector.MatchLabels).To(gomega.Equal(map[string]string{
+			"app.kubernetes.io/instance":  "site",
+			"app.kubernetes.io/component": "drupal",
+		}))
+		for k, v := range out.Spec.Selector.MatchLabels {
+			gomega.Expect(droplet.PodLabels()).To(gomega.HaveKeyWithValue(k, v))
+		}
+	})
+
+	ginkgo.It("updates the selector of an existing budget", func() {
+		out := &policyv1beta1.PodDisruptionBudget{
+			Spec: policyv1beta1.PodDisruptionBudgetSpec{
+				Selector: metav1.SetAsLabelSelector(droplet.PodLabels()),
+			},
+		}
+		sync(out)
+		gomega.Expect(out.Spec.Selector.MatchLabels).NotTo(gomega.HaveKey("app.kubernetes.io/version"))
+	})
+})
+
+var _ = ginkgo.Describe("HPA syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *drupal.Drupal
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = drupal.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com"},
+			},
+		})
+		droplet.SetDefaults()
+	})
+
+	sync := func() (*autoscalingv2beta1.HorizontalPodAutoscaler, error) {
+		s := NewHPASyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := s.GetObject().(*autoscalingv2beta1.HorizontalPodAutoscaler)
+		return out, s.SyncFn(out)
+	}
+
+	int32Ptr := func(i int32) *int32 { return &i }
+
+	ginkgo.It("fails without an autoscaling spec", func() {
+		_, err := sync()
+		gomega.Expect(err).To(gomega.MatchError(".spec.drupal.autoscaling is not defined"))
+	})
+
+	ginkgo.It("scales the drupal deployment", func() {
+		droplet.Spec.Drupal.AutoscalingSpec = &drupalv1beta1.AutoscalingSpec{MaxReplicas: 5}
+		out, err := sync()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(out.Name).To(gomega.Equal("site"))
+		gomega.Expect(out.Spec.ScaleTargetRef).To(gomega.Equal(autoscalingv2beta1.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       "site",
+		}))
+	})
+
+	table.DescribeTable("replicas and metrics",
+		func(spec *drupalv1beta1.AutoscalingSpec, minReplicas int32, resources []corev1.ResourceName) {
+			droplet.Spec.Drupal.AutoscalingSpec = spec
+			out, err := sync()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(out.Spec.MinReplicas).To(gomega.Equal(&minReplicas))
+			gomega.Expect(out.Spec.MaxReplicas).To(gomega.Equal(spec.MaxReplicas))
+
+			names := []corev1.ResourceName{}
+			for _, m := range out.Spec.Metrics {
+				names = append(names, m.Resource.Name)
+			}
+			gomega.Expect(names).To(gomega.Equal(resources))
+		},
+		table.Entry("defaults to one replica",
+			&drupalv1beta1.AutoscalingSpec{MaxReplicas: 5},
+			int32(1), []corev1.ResourceName{}),
+		table.Entry("cpu",
+			&drupalv1beta1.AutoscalingSpec{MinReplicas: int32Ptr(2), MaxReplicas: 5, TargetCPUUtilizationPercentage: int32Ptr(80)},
+			int32(2), []corev1.ResourceName{corev1.ResourceCPU}),
+		table.Entry("cpu and memory",
+			&drupalv1beta1.AutoscalingSpec{MaxReplicas: 5, TargetCPUUtilizationPercentage: int32Ptr(80), TargetMemoryUtilizationPercentage: int32Ptr(70)},
+			int32(1), []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}),
+	)
+})
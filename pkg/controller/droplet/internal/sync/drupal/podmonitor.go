@@ -0,0 +1,86 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var (
+	// PodMonitorGroupVersionKind is the kind of the Prometheus Operator PodMonitor
+	PodMonitorGroupVersionKind = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PodMonitor"}
+	// PodMonitorResource is the resource of the Prometheus Operator PodMonitor
+	PodMonitorResource = PodMonitorGroupVersionKind.GroupVersion().WithResource("podmonitors")
+)
+
+// NewPodMonitorSyncer returns a new sync.Interface for reconciling the
+// PodMonitor scraping the php-fpm and nginx exporters of the site. In
+// combined topology both exporters run in the drupal pods.
+func NewPodMonitorSyncer(droplet *drupal.Drupal, c dynamic.Interface, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(drupal.DrupalPodMonitor)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(PodMonitorGroupVersionKind)
+	obj.SetName(droplet.ComponentName(drupal.DrupalPodMonitor))
+	obj.SetNamespace(droplet.Namespace)
+
+	return syncer.NewUnstructuredSyncer("PodMonitor", droplet.Unwrap(), obj, PodMonitorResource, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*unstructured.Unstructured)
+		out.SetLabels(labels.Merge(labels.Merge(out.GetLabels(), objLabels), common.ControllerLabels))
+
+		endpoints := []interface{}{}
+		for _, port := range []string{"fpm-metrics", "nginx-metrics"} {
+			endpoint := map[string]interface{}{
+				"port": port,
+			}
+			if len(droplet.Spec.MonitoringSpec.Interval) > 0 {
+				endpoint["interval"] = droplet.Spec.MonitoringSpec.Interval
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+
+		fields := map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					"app.kubernetes.io/instance": droplet.Name,
+				},
+				"matchExpressions": []interface{}{
+					map[string]interface{}{
+						"key":      "app.kubernetes.io/component",
+						"operator": "In",
+						"values":   []interface{}{"drupal", "nginx"},
+					},
+				},
+			},
+			"podMetricsEndpoints": endpoints,
+		}
+		for k, v := range fields {
+			if err := unstructured.SetNestedField(out.Object, v, "spec", k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
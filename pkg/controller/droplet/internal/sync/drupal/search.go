@@ -0,0 +1,280 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/imdario/mergo"
+
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/mergo/transformers"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+const (
+	solrConfigSetPath = "/opt/solr/server/solr/configsets/drupal"
+	solrCoresPath     = "/opt/solr/server/solr/mycores"
+)
+
+var (
+	solrUserID int64 = 8983
+)
+
+func searchPodSpec(droplet *drupal.Drupal) corev1.PodSpec {
+	_, _, port, _ := droplet.SearchAddress()
+	managed := droplet.Spec.SearchSpec.Managed
+
+	// solr-precreate creates the core on first start, from the drupal config
+	// set if one is given
+	precreate := []string{droplet.SearchCore("")}
+	if managed.ConfigSetRef != nil {
+		precreate = append(precreate, solrConfigSetPath)
+	}
+	args := append([]string{"solr-precreate"}, precreate...)
+	if len(droplet.Spec.Sites) > 0 {
+		// the sites of a multisite get a core each, created the same way
+		script := []string{}
+		for _, core := range droplet.SearchCores() {
+			precreate[0] = core
+			script = append(script, "precreate-core "+strings.Join(precreate, " "))
+		}
+		args = []string{"/bin/sh", "-c", strings.Join(append(script, "exec solr-foreground"), " && ")}
+	}
+	mounts := []corev1.VolumeMount{
+		{
+			Name:      "data",
+			MountPath: solrCoresPath,
+		},
+	}
+	volumes := []corev1.Volume{}
+
+	if managed.ConfigSetRef != nil {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      "configset",
+			MountPath: solrConfigSetPath + "/conf",
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "configset",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: *managed.ConfigSetRef,
+				},
+			},
+		})
+	}
+
+	if managed.PersistentVolumeClaim == nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+
+	return corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name:  "solr",
+				Image: managed.Image,
+				Args:  args,
+				Ports: []corev1.ContainerPort{
+					{
+						Name:          "solr",
+						ContainerPort: port,
+					},
+				},
+				ReadinessProbe: &corev1.Probe{
+					Handler: corev1.Handler{
+						HTTPGet: &corev1.HTTPGetAction{
+							Path: fmt.Sprintf("/solr/%s/admin/ping", droplet.SearchCore("")),
+							Port: intstr.FromInt(int(port)),
+						},
+					},
+					InitialDelaySeconds: 10,
+					PeriodSeconds:       10,
+					TimeoutSeconds:      5,
+				},
+				VolumeMounts: mounts,
+				Resources:    managed.Resources,
+			},
+		},
+		Volumes: volumes,
+		SecurityContext: &corev1.PodSecurityContext{
+			FSGroup: &solrUserID,
+		},
+	}
+}
+
+// NewSearchStatefulSetSyncer returns a new sync.Interface for reconciling the
+// managed Solr StatefulSet
+func NewSearchStatefulSetSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(drupal.DrupalSearch)
+
+	obj := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.ComponentName(drupal.DrupalSearch),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("SearchStatefulSet", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*appsv1.StatefulSet)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		if !droplet.HasManagedSearch() {
+			return fmt.Errorf(".spec.search.managed is not defined")
+		}
+
+		selector := metav1.SetAsLabelSelector(droplet.SearchPodLabels())
+		if !reflect.DeepEqual(selector, out.Spec.Selector) {
+			if out.ObjectMeta.CreationTimestamp.IsZero() {
+				out.Spec.Selector = selector
+			} else {
+				return fmt.Errorf("statefulset selector is immutable")
+			}
+		}
+
+		// service name and volume claim templates are immutable
+		if out.ObjectMeta.CreationTimestamp.IsZero() {
+			out.Spec.ServiceName = droplet.ComponentName(drupal.DrupalSearch)
+			if droplet.Spec.SearchSpec.Managed.PersistentVolumeClaim != nil {
+				out.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "data",
+						},
+						Spec: *droplet.Spec.SearchSpec.Managed.PersistentVolumeClaim,
+					},
+				}
+			}
+		}
+
+		out.Spec.Template.ObjectMeta.Labels = droplet.SearchPodLabels()
+
+		err := mergo.Merge(&out.Spec.Template.Spec, searchPodSpec(droplet), mergo.WithTransformers(transformers.PodSpec))
+		if err != nil {
+			return err
+		}
+
+		out.Spec.Replicas = &oneReplica
+
+		return nil
+	})
+}
+
+// NewSearchServiceSyncer returns a new sync.Interface for reconciling the
+// managed Solr Service
+func NewSearchServiceSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(drupal.DrupalSearch)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.ComponentName(drupal.DrupalSearch),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("SearchService", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*corev1.Service)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		if !droplet.HasManagedSearch() {
+			return fmt.Errorf(".spec.search.managed is not defined")
+		}
+
+		selector := droplet.SearchPodLabels()
+		if !labels.Equals(selector, out.Spec.Selector) {
+			if out.ObjectMeta.CreationTimestamp.IsZero() {
+				out.Spec.Selector = selector
+			} else {
+				return fmt.Errorf("service selector is immutable")
+			}
+		}
+
+		if len(out.Spec.Ports) != 1 {
+			out.Spec.Ports = make([]corev1.ServicePort, 1)
+		}
+
+		_, _, port, _ := droplet.SearchAddress()
+		out.Spec.Ports[0].Name = "solr"
+		out.Spec.Ports[0].Port = port
+		out.Spec.Ports[0].TargetPort = intstr.FromInt(int(port))
+
+		return nil
+	})
+}
+
+// NewSearchReindexJobSyncer returns a new sync.Interface for reconciling the
+// Job reindexing the site's content for the current reindex token
+func NewSearchReindexJobSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(drupal.DrupalSearchReindex)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.ComponentName(drupal.DrupalSearchReindex),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	var (
+		backoffLimit          int32 = 2
+		activeDeadlineSeconds int64 = 3600
+	)
+
+	return syncer.NewObjectSyncer("SearchReindexJob", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*batchv1.Job)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		if !out.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		out.Spec.BackoffLimit = &backoffLimit
+		out.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+
+		// every site of a multisite indexes into a core of its own
+		script := []string{"drush search-api:reindex", "drush search-api:index"}
+		for _, site := range droplet.Spec.Sites {
+			script = append(script, siteDrush(site, "search-api:reindex"), siteDrush(site, "search-api:index"))
+		}
+		cmd := []string{"/bin/sh", "-c", strings.Join(script, " && ")}
+		template := droplet.JobPodTemplateSpec(cmd...)
+
+		out.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		err := mergo.Merge(&out.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
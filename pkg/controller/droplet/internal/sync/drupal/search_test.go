@@ -0,0 +1,104 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/drupal"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var _ = ginkgo.Describe("Search syncers", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *drupal.Drupal
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = drupal.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com"},
+				SearchSpec: &drupalv1beta1.SearchSpec{
+					Managed: &drupalv1beta1.ManagedSearchSpec{
+						ConfigSetRef: &corev1.LocalObjectReference{Name: "solr-config"},
+					},
+				},
+			},
+		})
+	})
+
+	solrArgs := func() []string {
+		drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+		s := NewSearchStatefulSetSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := s.GetObject().(*appsv1.StatefulSet)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		return out.Spec.Template.Spec.Containers[0].Args
+	}
+
+	reindexCommand := func() []string {
+		drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+		s := NewSearchReindexJobSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := s.GetObject().(*batchv1.Job)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		return out.Spec.Template.Spec.Containers[0].Args
+	}
+
+	ginkgo.It("precreates the core of a single site", func() {
+		gomega.Expect(solrArgs()).To(gomega.Equal([]string{
+			"solr-precreate", "drupal", "/opt/solr/server/solr/configsets/drupal",
+		}))
+	})
+
+	ginkgo.It("precreates a core for every site of a multisite", func() {
+		droplet.Spec.Sites = []drupalv1beta1.SiteSpec{
+			{Name: "blog", Domains: []drupalv1beta1.Domain{"blog.example.com"}},
+			{Name: "shop", Domains: []drupalv1beta1.Domain{"shop.example.com"}},
+		}
+		gomega.Expect(solrArgs()).To(gomega.Equal([]string{"/bin/sh", "-c",
+			"precreate-core drupal /opt/solr/server/solr/configsets/drupal && " +
+				"precreate-core drupal_blog /opt/solr/server/solr/configsets/drupal && " +
+				"precreate-core drupal_shop /opt/solr/server/solr/configsets/drupal && " +
+				"exec solr-foreground",
+		}))
+	})
+
+	ginkgo.It("reindexes every site of a multisite", func() {
+		droplet.Spec.Sites = []drupalv1beta1.SiteSpec{
+			{Name: "blog", Domains: []drupalv1beta1.Domain{"blog.example.com"}},
+		}
+		gomega.Expect(reindexCommand()).To(gomega.ContainElement(
+			"drush search-api:reindex && drush search-api:index && " +
+				"drush --uri=blog.example.com search-api:reindex && drush --uri=blog.example.com search-api:index",
+		))
+	})
+})
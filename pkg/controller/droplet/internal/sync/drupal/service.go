@@ -32,6 +32,7 @@ import (
 
 const (
 	drupalHTTPPort = 9000
+	nginxHTTPPort  = 80
 )
 
 // NewServiceSyncer returns a new sync.Interface for reconciling web Service
@@ -63,8 +64,14 @@ func NewServiceSyncer(droplet *drupal.Drupal, c client.Client, scheme *runtime.S
 		}
 
 		out.Spec.Ports[0].Name = "http"
-		out.Spec.Ports[0].Port = int32(9000)
-		out.Spec.Ports[0].TargetPort = intstr.FromInt(drupalHTTPPort)
+		if droplet.IsCombined() {
+			// the nginx sidecar serves http in combined topology
+			out.Spec.Ports[0].Port = int32(nginxHTTPPort)
+			out.Spec.Ports[0].TargetPort = intstr.FromInt(nginxHTTPPort)
+		} else {
+			out.Spec.Ports[0].Port = int32(9000)
+			out.Spec.Ports[0].TargetPort = intstr.FromInt(drupalHTTPPort)
+		}
 
 		return nil
 	})
@@ -0,0 +1,151 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/imdario/mergo"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/mergo/transformers"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+// siteDrush returns a drush command line running against a site of a
+// multisite
+func siteDrush(site drupalv1beta1.SiteSpec, args string) string {
+	return fmt.Sprintf("drush --uri=%s %s", site.Domains[0], args)
+}
+
+// NewSiteCronSyncer returns a new sync.Interface for reconciling the cron
+// CronJob of a site
+func NewSiteCronSyncer(droplet *drupal.Drupal, site drupalv1beta1.SiteSpec, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.SiteComponentLabels(drupal.DrupalCron, site.Name)
+
+	obj := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.SiteComponentName(drupal.DrupalCron, site.Name),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	var (
+		cronStartingDeadlineSeconds int64 = 10
+		backoffLimit                int32
+		successfulJobsHistoryLimit  int32 = 3
+		failedJobsHistoryLimit      int32 = 1
+	)
+
+	return syncer.NewObjectSyncer("SiteCron", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*batchv1beta1.CronJob)
+
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		out.Spec.Schedule = "*/15 * * * *"
+		out.Spec.ConcurrencyPolicy = "Forbid"
+		out.Spec.StartingDeadlineSeconds = &cronStartingDeadlineSeconds
+		out.Spec.SuccessfulJobsHistoryLimit = &successfulJobsHistoryLimit
+		out.Spec.FailedJobsHistoryLimit = &failedJobsHistoryLimit
+
+		out.Spec.JobTemplate.ObjectMeta.Labels = labels.Merge(objLabels, common.ControllerLabels)
+		out.Spec.JobTemplate.Spec.BackoffLimit = &backoffLimit
+
+		cmd := []string{"/bin/sh", "-c", siteDrush(site, "cron")}
+		template := droplet.JobPodTemplateSpec(cmd...)
+
+		out.Spec.JobTemplate.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		err := mergo.Merge(&out.Spec.JobTemplate.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// NewSiteDBUpgradeJobSyncer returns a new sync.Interface for reconciling the
+// database upgrade Job of a site
+func NewSiteDBUpgradeJobSyncer(droplet *drupal.Drupal, site drupalv1beta1.SiteSpec, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.SiteComponentName(drupal.DrupalDBUpgrade, site.Name),
+			Namespace: droplet.Namespace,
+		},
+	}
+	objLabels := droplet.SiteComponentLabels(drupal.DrupalDBUpgrade, site.Name)
+	// sites which are not installed yet have nothing to upgrade
+	cmd := fmt.Sprintf("if %s | grep -q Successful; then %s && %s; fi",
+		siteDrush(site, "status --field=bootstrap"), siteDrush(site, "updatedb -y"), siteDrush(site, "cr"))
+
+	return newSiteJobSyncer("SiteDBUpgradeJob", droplet, obj, objLabels, cmd, c, scheme)
+}
+
+// NewSiteInstallJobSyncer returns a new sync.Interface for reconciling the
+// Job installing a site with its install profile
+func NewSiteInstallJobSyncer(droplet *drupal.Drupal, site drupalv1beta1.SiteSpec, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.SiteComponentName(drupal.DrupalSiteInstall, site.Name),
+			Namespace: droplet.Namespace,
+		},
+	}
+	objLabels := droplet.SiteComponentLabels(drupal.DrupalSiteInstall, site.Name)
+	// skip the install if the site bootstraps already
+	cmd := fmt.Sprintf("%s | grep -q Successful || %s",
+		siteDrush(site, "status --field=bootstrap"),
+		siteDrush(site, fmt.Sprintf("site-install -y %s --sites-subdir=%s", site.InstallProfile, site.Name)))
+
+	return newSiteJobSyncer("SiteInstallJob", droplet, obj, objLabels, cmd, c, scheme)
+}
+
+// newSiteJobSyncer returns a sync.Interface for a Job running a shell command
+// once, when the Job gets created
+func newSiteJobSyncer(name string, droplet *drupal.Drupal, obj *batchv1.Job, objLabels labels.Set, cmd string, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	var backoffLimit int32
+
+	return syncer.NewObjectSyncer(name, droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*batchv1.Job)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		if !out.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		out.Spec.BackoffLimit = &backoffLimit
+
+		template := droplet.JobPodTemplateSpec("/bin/sh", "-c", cmd)
+
+		out.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		err := mergo.Merge(&out.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
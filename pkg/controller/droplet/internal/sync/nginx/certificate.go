@@ -0,0 +1,108 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var (
+	// CertificateGroupVersionKind is the kind of the cert-manager Certificate
+	CertificateGroupVersionKind = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	// CertificateResource is the resource of the cert-manager Certificate
+	CertificateResource = CertificateGroupVersionKind.GroupVersion().WithResource("certificates")
+)
+
+// NewCertificateSyncer returns a new sync.Interface for reconciling the
+// cert-manager Certificate covering all domains of the site
+func NewCertificateSyncer(droplet *nginx.Nginx, c dynamic.Interface, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(nginx.NginxCertificate)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(CertificateGroupVersionKind)
+	obj.SetName(droplet.ComponentName(nginx.NginxCertificate))
+	obj.SetNamespace(droplet.Namespace)
+
+	return syncer.NewUnstructuredSyncer("Certificate", droplet.Unwrap(), obj, CertificateResource, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*unstructured.Unstructured)
+		out.SetLabels(labels.Merge(labels.Merge(out.GetLabels(), objLabels), common.ControllerLabels))
+
+		if !droplet.HasCertificate() {
+			return fmt.Errorf(".spec.tls.issuerRef is not defined")
+		}
+		issuer := droplet.Spec.TLSSpec.IssuerRef
+
+		dnsNames := []interface{}{}
+		for _, d := range droplet.Spec.AllDomains() {
+			dnsNames = append(dnsNames, string(d))
+		}
+
+		fields := map[string]interface{}{
+			"secretName": droplet.TLSSecretName(),
+			"dnsNames":   dnsNames,
+			"issuerRef": map[string]interface{}{
+				"name":  issuer.Name,
+				"kind":  issuer.Kind,
+				"group": issuer.Group,
+			},
+		}
+		for k, v := range fields {
+			if err := unstructured.SetNestedField(out.Object, v, "spec", k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// CertificateCondition returns the CertificateReady condition of a droplet,
+// as reported by the Ready condition of its cert-manager Certificate
+func CertificateCondition(obj *unstructured.Unstructured) drupalv1beta1.DropletCondition {
+	out := drupalv1beta1.DropletCondition{
+		Type:    drupalv1beta1.CertificateReady,
+		Status:  corev1.ConditionUnknown,
+		Reason:  "Pending",
+		Message: "cert-manager has not reported on the certificate yet",
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		if status, ok := condition["status"].(string); ok {
+			out.Status = corev1.ConditionStatus(status)
+		}
+		out.Reason, _ = condition["reason"].(string)
+		out.Message, _ = condition["message"].(string)
+	}
+
+	return out
+}
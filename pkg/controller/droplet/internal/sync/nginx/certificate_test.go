@@ -0,0 +1,142 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"context"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/nginx"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var _ = ginkgo.Describe("Certificate syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		client  *fake.FakeDynamicClient
+		droplet *nginx.Nginx
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		client = fake.NewSimpleDynamicClient(scheme)
+
+		droplet = nginx.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+				UID:       "site-uid",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com", "www.example.com"},
+				TLSSpec: &drupalv1beta1.TLSSpec{
+					IssuerRef: &drupalv1beta1.CertIssuerRef{
+						Name:  "letsencrypt",
+						Kind:  "ClusterIssuer",
+						Group: "cert-manager.io",
+					},
+				},
+			},
+		})
+	})
+
+	getCertificate := func() *unstructured.Unstructured {
+		obj, err := client.Resource(CertificateResource).Namespace("default").Get(context.TODO(), "site-tls", metav1.GetOptions{})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		return obj
+	}
+
+	ginkgo.It("creates a certificate covering all domains", func() {
+		s := NewCertificateSyncer(droplet, client, scheme)
+		gomega.Expect(syncer.Sync(context.TODO(), s, nil)).To(gomega.Succeed())
+
+		obj := getCertificate()
+		gomega.Expect(obj.GetKind()).To(gomega.Equal("Certificate"))
+
+		dnsNames, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "dnsNames")
+		gomega.Expect(dnsNames).To(gomega.Equal([]string{"example.com", "www.example.com"}))
+
+		secretName, _, _ := unstructured.NestedString(obj.Object, "spec", "secretName")
+		gomega.Expect(secretName).To(gomega.Equal(droplet.TLSSecretName()))
+
+		issuer, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "issuerRef")
+		gomega.Expect(issuer).To(gomega.Equal(map[string]string{
+			"name":  "letsencrypt",
+			"kind":  "ClusterIssuer",
+			"group": "cert-manager.io",
+		}))
+
+		gomega.Expect(obj.GetOwnerReferences()).To(gomega.HaveLen(1))
+		gomega.Expect(obj.GetOwnerReferences()[0].Name).To(gomega.Equal("site"))
+	})
+
+	ginkgo.It("updates the certificate when domains change", func() {
+		gomega.Expect(syncer.Sync(context.TODO(), NewCertificateSyncer(droplet, client, scheme), nil)).To(gomega.Succeed())
+
+		droplet.Spec.Domains = append(droplet.Spec.Domains, "example.org")
+		gomega.Expect(syncer.Sync(context.TODO(), NewCertificateSyncer(droplet, client, scheme), nil)).To(gomega.Succeed())
+
+		dnsNames, _, _ := unstructured.NestedStringSlice(getCertificate().Object, "spec", "dnsNames")
+		gomega.Expect(dnsNames).To(gomega.ContainElement("example.org"))
+	})
+
+	ginkgo.It("deletes the certificate it controls", func() {
+		s := NewCertificateSyncer(droplet, client, scheme)
+		gomega.Expect(syncer.Sync(context.TODO(), s, nil)).To(gomega.Succeed())
+
+		gomega.Expect(s.(*syncer.UnstructuredSyncer).Delete(context.TODO())).To(gomega.Succeed())
+		_, err := client.Resource(CertificateResource).Namespace("default").Get(context.TODO(), "site-tls", metav1.GetOptions{})
+		gomega.Expect(err).To(gomega.HaveOccurred())
+	})
+})
+
+var _ = ginkgo.Describe("CertificateCondition", func() {
+	ginkgo.It("is unknown until cert-manager reports", func() {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		gomega.Expect(CertificateCondition(obj).Status).To(gomega.Equal(corev1.ConditionUnknown))
+	})
+
+	ginkgo.It("follows the Ready condition of the certificate", func() {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":    "Ready",
+						"status":  "False",
+						"reason":  "Issuing",
+						"message": "Issuing certificate as Secret does not exist",
+					},
+				},
+			},
+		}}
+
+		condition := CertificateCondition(obj)
+		gomega.Expect(condition.Type).To(gomega.Equal(drupalv1beta1.CertificateReady))
+		gomega.Expect(condition.Status).To(gomega.Equal(corev1.ConditionFalse))
+		gomega.Expect(condition.Reason).To(gomega.Equal("Issuing"))
+	})
+})
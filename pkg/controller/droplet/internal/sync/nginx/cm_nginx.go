@@ -17,6 +17,10 @@ package sync
 
 import (
 	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
 	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
 	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/templates"
 	"github.com/sylus/drupal-operator/pkg/internal/nginx"
@@ -32,23 +37,210 @@ import (
 
 // Settings spec
 type Settings struct {
-	Domain     string
-	Host       string
-	s3BasePath string
-	Resolver   string
+	// Domains are served by the site, redirected domains left out
+	Domains      []string
+	Redirects    []DomainRedirect
+	ForceHTTPS   []string
+	Host         string
+	MediaBaseURL string
+	Resolver     string
+	// MediaPath is the URL path of the media files mounted into the nginx
+	// pods, empty if they are not served by nginx
+	MediaPath string
+	Cache     *PageCacheSettings
+	// StubStatusPort is the loopback port of the stub_status server, zero
+	// when monitoring is disabled
+	StubStatusPort int
+}
+
+// DomainRedirect redirects all requests to a domain
+type DomainRedirect struct {
+	Domain string
+	Target string
+	// Scheme of the target, either https or $forwarded_scheme to keep the
+	// scheme of the request
+	Scheme string
+}
+
+// domainSettings sorts the domains of all sites into served and redirected
+// domains
+func domainSettings(droplet *nginx.Nginx) (domains []string, redirects []DomainRedirect, forceHTTPS []string) {
+	options := map[drupalv1beta1.Domain]drupalv1beta1.DomainOptions{}
+	for _, o := range droplet.Spec.DomainOptions {
+		options[o.Domain] = o
+	}
+
+	// redirectTo main redirects to the first domain of the same site
+	sites := [][]drupalv1beta1.Domain{droplet.Spec.Domains}
+	for _, site := range droplet.Spec.Sites {
+		sites = append(sites, site.Domains)
+	}
+
+	for _, siteDomains := range sites {
+		for i, d := range siteDomains {
+			domain := string(d)
+			o := options[d]
+
+			target := domain
+			switch {
+			case o.RedirectTo == "main" && i > 0:
+				target = string(siteDomains[0])
+			case o.WWW == drupalv1beta1.AddWWW && !strings.HasPrefix(domain, "www."):
+				target = "www." + domain
+			case o.WWW == drupalv1beta1.RemoveWWW && strings.HasPrefix(domain, "www."):
+				target = strings.TrimPrefix(domain, "www.")
+			}
+
+			if target == domain {
+				domains = append(domains, domain)
+				if o.ForceHTTPS {
+					forceHTTPS = append(forceHTTPS, domain)
+				}
+				continue
+			}
+
+			redirect := DomainRedirect{
+				Domain: domain,
+				Target: target,
+				Scheme: "$forwarded_scheme",
+			}
+			if o.ForceHTTPS {
+				redirect.Scheme = "https"
+			}
+			redirects = append(redirects, redirect)
+		}
+	}
+
+	return domains, redirects, forceHTTPS
+}
+
+// PageCacheSettings spec for caching the pages rendered by Drupal
+type PageCacheSettings struct {
+	Path string
+	// Size in megabytes
+	Size int64
+	// TTL and NotFoundTTL in seconds
+	TTL         int64
+	NotFoundTTL int64
+	// BypassCookies is a regular expression matching the Cookie header of
+	// requests which bypass the cache
+	BypassCookies string
+	// PurgeAllowFrom is nil if purging is disabled
+	PurgeAllowFrom []*net.IPNet
+}
+
+var cookieNameRegexp = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+func newPageCacheSettings(droplet *nginx.Nginx) (*PageCacheSettings, error) {
+	if !droplet.HasPageCache() {
+		return nil, nil
+	}
+	spec := droplet.Spec.Nginx.CacheSpec
+
+	cookies := []string{}
+	for _, name := range spec.BypassCookies {
+		if !cookieNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf(".spec.nginx.cache.bypassCookies: invalid cookie name %q", name)
+		}
+		cookies = append(cookies, regexp.QuoteMeta(name))
+	}
+
+	settings := &PageCacheSettings{
+		Path:        nginx.PageCachePath,
+		Size:        spec.Size.Value() / (1024 * 1024),
+		TTL:         int64(spec.TTL.Seconds()),
+		NotFoundTTL: int64(spec.NotFoundTTL.Seconds()),
+	}
+	if len(cookies) > 0 {
+		settings.BypassCookies = fmt.Sprintf(`(^|;\s*)(%s)`, strings.Join(cookies, "|"))
+	}
+
+	if spec.Purge != nil {
+		settings.PurgeAllowFrom = []*net.IPNet{}
+		for _, addr := range spec.Purge.AllowFrom {
+			cidr := addr
+			if !strings.Contains(cidr, "/") {
+				if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+					cidr += "/32"
+				} else {
+					cidr += "/128"
+				}
+			}
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf(".spec.nginx.cache.purge.allowFrom: invalid address or CIDR %q", addr)
+			}
+			settings.PurgeAllowFrom = append(settings.PurgeAllowFrom, ipNet)
+		}
+	}
+
+	return settings, nil
+}
+
+// mediaBaseURL returns the URL under which the object storage bucket serves
+// the media files or an empty string if media files are not stored in object
+// storage
+func mediaBaseURL(droplet *nginx.Nginx) string {
+	media := droplet.Spec.Drupal.MediaVolumeSpec
+	if media == nil {
+		return ""
+	}
+
+	var base, prefix string
+	switch {
+	case media.S3VolumeSource != nil:
+		if len(media.S3VolumeSource.Endpoint) > 0 {
+			base = fmt.Sprintf("%s/%s", strings.TrimSuffix(media.S3VolumeSource.Endpoint, "/"), media.S3VolumeSource.Bucket)
+		} else {
+			base = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", media.S3VolumeSource.Bucket, media.S3VolumeSource.Region)
+		}
+		prefix = media.S3VolumeSource.PathPrefix
+		if media.S3VolumeSource.Module == "s3fs" {
+			// s3fs keeps public files in a folder of its own
+			prefix = path.Join(prefix, "s3fs-public")
+		}
+	case media.GCSVolumeSource != nil:
+		base = fmt.Sprintf("https://storage.googleapis.com/%s", media.GCSVolumeSource.Bucket)
+		prefix = media.GCSVolumeSource.PathPrefix
+	case media.AzureBlobVolumeSource != nil:
+		base = fmt.Sprintf("https://%s.blob.core.windows.net/%s", media.AzureBlobVolumeSource.Account, media.AzureBlobVolumeSource.Container)
+		prefix = media.AzureBlobVolumeSource.PathPrefix
+	default:
+		return ""
+	}
+
+	prefix = strings.Trim(prefix, "/")
+	if len(prefix) > 0 {
+		return fmt.Sprintf("%s/%s", base, prefix)
+	}
+	return base
 }
 
 // NewConfigMapSyncer returns a new sync.Interface for reconciling Nginx ConfigMap
 func NewConfigMapSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Scheme) syncer.Interface {
 	objLabels := droplet.ComponentLabels(nginx.NginxConfigMap)
 
+	// php-fpm listens on localhost when nginx runs in the drupal pods
+	fastcgiHost := droplet.Name
+	if droplet.IsCombined() {
+		fastcgiHost = "127.0.0.1"
+	}
+
+	domains, redirects, forceHTTPS := domainSettings(droplet)
 	templateInput := Settings{
-		Domain:     "drupal.innovation.cloud.statcan.ca",
-		Host:       "mysite",
-		s3BasePath: "stcdrupal.blob.core.windows.net/drupal-public",
-		Resolver:   "10.0.0.10",
+		Domains:      domains,
+		Redirects:    redirects,
+		ForceHTTPS:   forceHTTPS,
+		Host:         fastcgiHost,
+		MediaBaseURL: mediaBaseURL(droplet),
+		Resolver:     droplet.Spec.Nginx.Resolver,
+	}
+	if droplet.HasMediaVolume() {
+		templateInput.MediaPath = droplet.MediaURLPath()
+	}
+	if droplet.HasMonitoring() {
+		templateInput.StubStatusPort = nginx.StubStatusPort
 	}
-	configMap := common.GenerateConfig(templateInput, templates.ConfigMapNginx)
 
 	obj := &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
@@ -59,15 +251,26 @@ func NewConfigMapSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.S
 			Name:      fmt.Sprintf("%s-%s", droplet.ComponentName(nginx.NginxConfigMap), "nginx"),
 			Namespace: droplet.Namespace,
 		},
-		Data: map[string]string{
-			"nginx.conf": *configMap,
-		},
 	}
 
 	return syncer.NewObjectSyncer("ConfigMap", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
 		out := existing.(*corev1.ConfigMap)
 		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
 
+		cache, err := newPageCacheSettings(droplet)
+		if err != nil {
+			return err
+		}
+		templateInput.Cache = cache
+
+		config, err := common.GenerateConfig("nginx.conf", templateInput, templates.ConfigMapNginx)
+		if err != nil {
+			return err
+		}
+		out.Data = map[string]string{
+			"nginx.conf": config,
+		}
+
 		return nil
 	})
 }
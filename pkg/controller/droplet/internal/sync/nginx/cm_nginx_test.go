@@ -0,0 +1,262 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	"github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/nginx"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var _ = ginkgo.Describe("ConfigMap syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *nginx.Nginx
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = nginx.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com"},
+			},
+		})
+	})
+
+	nginxConf := func() string {
+		s := NewConfigMapSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := &corev1.ConfigMap{}
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		return out.Data["nginx.conf"]
+	}
+
+	table.DescribeTable("media location",
+		func(media *drupalv1beta1.MediaVolumeSpec, location string) {
+			droplet.Spec.Drupal.MediaVolumeSpec = media
+			out := nginxConf()
+			if len(location) > 0 {
+				gomega.Expect(out).To(gomega.ContainSubstring("location ^~ " + location + " {"))
+			} else {
+				gomega.Expect(out).NotTo(gomega.ContainSubstring("location ^~ /"))
+			}
+		},
+		table.Entry("defaults to sites/default/files",
+			&drupalv1beta1.MediaVolumeSpec{HostPath: &corev1.HostPathVolumeSource{Path: "/data"}},
+			"/sites/default/files/"),
+		table.Entry("follows the mount path",
+			&drupalv1beta1.MediaVolumeSpec{
+				MountPath: "/var/www/html/sites/example/files",
+				HostPath:  &corev1.HostPathVolumeSource{Path: "/data"},
+			},
+			"/sites/example/files/"),
+		table.Entry("is left out for mounts outside the webroot",
+			&drupalv1beta1.MediaVolumeSpec{
+				MountPath: "/mnt/media",
+				HostPath:  &corev1.HostPathVolumeSource{Path: "/data"},
+			},
+			""),
+		table.Entry("is left out without a media volume", nil, ""),
+	)
+
+	table.DescribeTable("object storage resolver",
+		func(resolver, expected string) {
+			droplet.Spec.Drupal.MediaVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+				S3VolumeSource: &drupalv1beta1.S3VolumeSource{Bucket: "media"},
+			}
+			droplet.Spec.Nginx.Resolver = resolver
+			drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+			gomega.Expect(nginxConf()).To(gomega.ContainSubstring("resolver " + expected + " valid=5s ipv6=off;"))
+		},
+		table.Entry("defaults to kube-dns", "", "kube-dns.kube-system.svc"),
+		table.Entry("follows the spec", "10.96.0.10", "10.96.0.10"),
+	)
+
+	ginkgo.Describe("page cache", func() {
+		syncErr := func() error {
+			s := NewConfigMapSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+			return s.SyncFn(&corev1.ConfigMap{})
+		}
+
+		ginkgo.BeforeEach(func() {
+			droplet.Spec.Nginx.CacheSpec = &drupalv1beta1.PageCacheSpec{}
+		})
+
+		table.DescribeTable("bypass cookies",
+			func(cookies []string, expected string) {
+				droplet.Spec.Nginx.CacheSpec.BypassCookies = cookies
+				drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+				gomega.Expect(nginxConf()).To(gomega.ContainSubstring(expected))
+			},
+			table.Entry("defaults to the session cookies", nil,
+				`"~(^|;\s*)(SESS|SSESS|NO_CACHE)" 1;`),
+			table.Entry("quotes the cookie names", []string{"my.session", "NO_CACHE"},
+				`"~(^|;\s*)(my\.session|NO_CACHE)" 1;`),
+		)
+
+		table.DescribeTable("rejects invalid cookie names",
+			func(name string) {
+				droplet.Spec.Nginx.CacheSpec.BypassCookies = []string{"SESS", name}
+				drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+				gomega.Expect(syncErr()).To(gomega.MatchError(gomega.ContainSubstring("invalid cookie name")))
+			},
+			table.Entry("separator", "SESS;"),
+			table.Entry("space", "NO CACHE"),
+			table.Entry("quote", `SESS"`),
+		)
+
+		ginkgo.It("leaves out the purge endpoint without purge", func() {
+			drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+			gomega.Expect(nginxConf()).NotTo(gomega.ContainSubstring("purge"))
+		})
+
+		ginkgo.It("serves the purge endpoint under a prefix location", func() {
+			droplet.Spec.Nginx.CacheSpec.Purge = &drupalv1beta1.PageCachePurgeSpec{AllowFrom: []string{"10.0.0.0/8"}}
+			drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+			out := nginxConf()
+			gomega.Expect(out).To(gomega.ContainSubstring("location ^~ /purge/ {"))
+			gomega.Expect(out).To(gomega.ContainSubstring(`"~^/purge(?<purge_path>/.*)$" $purge_path;`))
+			gomega.Expect(out).To(gomega.ContainSubstring(`fastcgi_cache_purge drupal "${scheme}GET$host$purge_request_uri";`))
+		})
+
+		table.DescribeTable("purge allowFrom",
+			func(allowFrom []string, expected []string) {
+				droplet.Spec.Nginx.CacheSpec.Purge = &drupalv1beta1.PageCachePurgeSpec{AllowFrom: allowFrom}
+				drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+				out := nginxConf()
+				for _, allow := range expected {
+					gomega.Expect(out).To(gomega.ContainSubstring("allow " + allow + ";"))
+				}
+			},
+			table.Entry("defaults to the private networks", nil,
+				[]string{"127.0.0.1/32", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}),
+			table.Entry("addresses", []string{"192.0.2.1", "2001:db8::1"},
+				[]string{"192.0.2.1/32", "2001:db8::1/128"}),
+			table.Entry("CIDRs", []string{"192.0.2.0/24", "2001:db8::/32"},
+				[]string{"192.0.2.0/24", "2001:db8::/32"}),
+		)
+
+		table.DescribeTable("rejects invalid purge allowFrom entries",
+			func(addr string) {
+				droplet.Spec.Nginx.CacheSpec.Purge = &drupalv1beta1.PageCachePurgeSpec{AllowFrom: []string{"10.0.0.0/8", addr}}
+				drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+				gomega.Expect(syncErr()).To(gomega.MatchError(gomega.ContainSubstring("invalid address or CIDR")))
+			},
+			table.Entry("hostname", "example.com"),
+			table.Entry("prefix length", "10.0.0.0/33"),
+			table.Entry("address", "10.0.0.256"),
+		)
+	})
+
+	ginkgo.Describe("domains", func() {
+		type redirect struct {
+			domain, target string
+		}
+
+		table.DescribeTable("redirects",
+			func(spec drupalv1beta1.DropletSpec, serverName string, redirects []redirect) {
+				droplet.Spec.Domains = spec.Domains
+				droplet.Spec.Sites = spec.Sites
+				droplet.Spec.DomainOptions = spec.DomainOptions
+				out := nginxConf()
+
+				gomega.Expect(out).To(gomega.ContainSubstring("server_name " + serverName + ";"))
+				gomega.Expect(strings.Count(out, "return 301 ")).To(gomega.Equal(len(redirects)))
+				for _, r := range redirects {
+					gomega.Expect(out).To(gomega.ContainSubstring(fmt.Sprintf("server_name %s;\n\t\t\treturn 301 %s$request_uri;", r.domain, r.target)))
+				}
+			},
+			table.Entry("serves all domains without options",
+				drupalv1beta1.DropletSpec{Domains: []drupalv1beta1.Domain{"example.com", "www.example.com"}},
+				"example.com www.example.com", nil),
+			table.Entry("redirects to the main domain",
+				drupalv1beta1.DropletSpec{
+					Domains: []drupalv1beta1.Domain{"example.com", "example.org"},
+					DomainOptions: []drupalv1beta1.DomainOptions{
+						{Domain: "example.com", RedirectTo: "main"},
+						{Domain: "example.org", RedirectTo: "main"},
+					},
+				},
+				"example.com", []redirect{{"example.org", "$forwarded_scheme://example.com"}}),
+			table.Entry("adds www.",
+				drupalv1beta1.DropletSpec{
+					Domains:       []drupalv1beta1.Domain{"example.com", "www.example.com"},
+					DomainOptions: []drupalv1beta1.DomainOptions{{Domain: "example.com", WWW: drupalv1beta1.AddWWW}},
+				},
+				"www.example.com", []redirect{{"example.com", "$forwarded_scheme://www.example.com"}}),
+			table.Entry("removes www.",
+				drupalv1beta1.DropletSpec{
+					Domains: []drupalv1beta1.Domain{"example.com", "www.example.com"},
+					DomainOptions: []drupalv1beta1.DomainOptions{
+						{Domain: "www.example.com", WWW: drupalv1beta1.RemoveWWW},
+						{Domain: "example.com", WWW: drupalv1beta1.RemoveWWW},
+					},
+				},
+				"example.com", []redirect{{"www.example.com", "$forwarded_scheme://example.com"}}),
+			table.Entry("redirects to https",
+				drupalv1beta1.DropletSpec{
+					Domains:       []drupalv1beta1.Domain{"example.com", "example.org"},
+					DomainOptions: []drupalv1beta1.DomainOptions{{Domain: "example.org", RedirectTo: "main", ForceHTTPS: true}},
+				},
+				"example.com", []redirect{{"example.org", "https://example.com"}}),
+			table.Entry("prefers the main domain over www.",
+				drupalv1beta1.DropletSpec{
+					Domains:       []drupalv1beta1.Domain{"example.com", "example.org"},
+					DomainOptions: []drupalv1beta1.DomainOptions{{Domain: "example.org", RedirectTo: "main", WWW: drupalv1beta1.AddWWW}},
+				},
+				"example.com", []redirect{{"example.org", "$forwarded_scheme://example.com"}}),
+			table.Entry("redirects to the main domain of the same site",
+				drupalv1beta1.DropletSpec{
+					Domains: []drupalv1beta1.Domain{"example.com"},
+					Sites: []drupalv1beta1.SiteSpec{{
+						Name:    "blog",
+						Domains: []drupalv1beta1.Domain{"blog.example.com", "blog.example.org"},
+					}},
+					DomainOptions: []drupalv1beta1.DomainOptions{{Domain: "blog.example.org", RedirectTo: "main"}},
+				},
+				"example.com blog.example.com", []redirect{{"blog.example.org", "$forwarded_scheme://blog.example.com"}}),
+		)
+
+		ginkgo.It("forces https on the served domains", func() {
+			droplet.Spec.Domains = []drupalv1beta1.Domain{"example.com", "example.org"}
+			droplet.Spec.DomainOptions = []drupalv1beta1.DomainOptions{{Domain: "example.com", ForceHTTPS: true}}
+			out := nginxConf()
+			gomega.Expect(out).To(gomega.ContainSubstring("map $host $force_https {\n\t\tdefault 0;\n\t\texample.com 1;\n\t}"))
+			gomega.Expect(out).To(gomega.ContainSubstring(`if ($force_https$forwarded_scheme = "1http") {`))
+		})
+
+		ginkgo.It("leaves out the https map without forced domains", func() {
+			gomega.Expect(nginxConf()).NotTo(gomega.ContainSubstring("$force_https"))
+		})
+	})
+})
@@ -39,7 +39,7 @@ func NewDeploymentSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.
 
 	obj := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s", droplet.ComponentName(nginx.NginxDeployment), "nginx"),
+			Name:      deploymentName(droplet),
 			Namespace: droplet.Namespace,
 		},
 	}
@@ -70,10 +70,28 @@ func NewDeploymentSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.
 			return err
 		}
 
-		if droplet.Spec.Nginx.Replicas != nil {
+		// the HorizontalPodAutoscaler owns the replica count, only set the
+		// initial value
+		if droplet.Spec.Nginx.Replicas != nil && (!droplet.IsAutoscaled() || out.Spec.Replicas == nil) {
 			out.Spec.Replicas = droplet.Spec.Nginx.Replicas
 		}
 
 		return nil
 	})
 }
+
+// NewHPASyncer returns a new sync.Interface for reconciling Nginx HorizontalPodAutoscaler
+func NewHPASyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	return common.NewHPASyncer(droplet.Unwrap(), droplet.ComponentName(nginx.NginxHPA), droplet.ComponentLabels(nginx.NginxHPA),
+		deploymentName(droplet), droplet.Spec.Nginx.AutoscalingSpec, ".spec.nginx.autoscaling", c, scheme)
+}
+
+// NewPDBSyncer returns a new sync.Interface for reconciling Nginx PodDisruptionBudget
+func NewPDBSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	return common.NewPDBSyncer(droplet.Unwrap(), droplet.ComponentName(nginx.NginxPDB), droplet.ComponentLabels(nginx.NginxPDB),
+		droplet.PodLabels(), droplet.Spec.Nginx.PodDisruptionBudgetSpec, c, scheme)
+}
+
+func deploymentName(droplet *nginx.Nginx) string {
+	return fmt.Sprintf("%s-%s", droplet.ComponentName(nginx.NginxDeployment), "nginx")
+}
@@ -0,0 +1,143 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/nginx"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var _ = ginkgo.Describe("Deployment syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *nginx.Nginx
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = nginx.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains:  []drupalv1beta1.Domain{"example.com"},
+				Topology: drupalv1beta1.SplitTopology,
+			},
+		})
+	})
+
+	sync := func(out *appsv1.Deployment) *appsv1.Deployment {
+		s := NewDeploymentSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		return out
+	}
+
+	ginkgo.It("mounts the built code artifact instead of cloning it", func() {
+		droplet.Spec.Drupal.CodeVolumeSpec = &drupalv1beta1.CodeVolumeSpec{
+			GitDir: &drupalv1beta1.GitVolumeSource{
+				Repository: "https://github.com/example/site.git",
+				GitRef:     "0123456789abcdef0123456789abcdef01234567",
+				Artifact:   &drupalv1beta1.CodeArtifactSpec{},
+			},
+		}
+		spec := sync(&appsv1.Deployment{}).Spec.Template.Spec
+
+		names := []string{}
+		for _, c := range spec.InitContainers {
+			names = append(names, c.Name)
+		}
+		gomega.Expect(names).To(gomega.Equal([]string{"copy-webroot", "wait-for-code"}))
+
+		gomega.Expect(spec.Volumes).To(gomega.ContainElement(corev1.Volume{
+			Name: "code",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "site-code-artifact"},
+			},
+		}))
+		for _, m := range spec.Containers[0].VolumeMounts {
+			if m.Name == "code" {
+				gomega.Expect(m.ReadOnly).To(gomega.BeTrue())
+				gomega.Expect(m.SubPath).To(gomega.Equal("0123456789ab"))
+			}
+		}
+	})
+})
+
+var _ = ginkgo.Describe("PDB and HPA syncers", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *nginx.Nginx
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = nginx.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains:  []drupalv1beta1.Domain{"example.com"},
+				Topology: drupalv1beta1.SplitTopology,
+			},
+		})
+	})
+
+	ginkgo.It("selects the nginx pods by their stable labels", func() {
+		s := NewPDBSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := s.GetObject().(*policyv1beta1.PodDisruptionBudget)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		gomega.Expect(out.Name).To(gomega.Equal("site-nginx"))
+		gomega.Expect(out.Spec.Selector.MatchLabels).To(gomega.Equal(map[string]string{
+			"app.kubernetes.io/instance":  "site",
+			"app.kubernetes.io/component": "nginx",
+		}))
+	})
+
+	ginkgo.It("scales the nginx deployment", func() {
+		droplet.Spec.Nginx.AutoscalingSpec = &drupalv1beta1.AutoscalingSpec{MaxReplicas: 3}
+		s := NewHPASyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := s.GetObject().(*autoscalingv2beta1.HorizontalPodAutoscaler)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+
+		deployment := NewDeploymentSyncer(droplet, nil, scheme).GetObject().(*appsv1.Deployment)
+		gomega.Expect(out.Spec.ScaleTargetRef.Name).To(gomega.Equal(deployment.Name))
+		gomega.Expect(out.Spec.ScaleTargetRef.Name).To(gomega.Equal("site-nginx"))
+	})
+
+	ginkgo.It("fails without an autoscaling spec", func() {
+		s := NewHPASyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		out := s.GetObject().(*autoscalingv2beta1.HorizontalPodAutoscaler)
+		gomega.Expect(s.SyncFn(out)).To(gomega.MatchError(".spec.nginx.autoscaling is not defined"))
+	})
+})
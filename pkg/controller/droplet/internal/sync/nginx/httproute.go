@@ -0,0 +1,139 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var (
+	// HTTPRouteGroupVersionKind is the kind of the Gateway API HTTPRoute
+	HTTPRouteGroupVersionKind = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+	// HTTPRouteResource is the resource of the Gateway API HTTPRoute
+	HTTPRouteResource = HTTPRouteGroupVersionKind.GroupVersion().WithResource("httproutes")
+)
+
+// The subset of the Gateway API HTTPRoute spec set by the operator. Fields
+// defaulted by the apiserver are set explicitly, so unchanged routes don't get
+// updated.
+type httpRouteSpec struct {
+	ParentRefs []httpParentRef `json:"parentRefs"`
+	Hostnames  []string        `json:"hostnames"`
+	Rules      []httpRouteRule `json:"rules"`
+}
+
+type httpParentRef struct {
+	Group       string `json:"group"`
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace,omitempty"`
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+type httpRouteRule struct {
+	Matches     []httpRouteMatch                `json:"matches"`
+	Filters     []drupalv1beta1.HTTPRouteFilter `json:"filters,omitempty"`
+	BackendRefs []httpBackendRef                `json:"backendRefs"`
+}
+
+type httpRouteMatch struct {
+	Path httpPathMatch `json:"path"`
+}
+
+type httpPathMatch struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type httpBackendRef struct {
+	Group  string `json:"group"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Port   int32  `json:"port"`
+	Weight int32  `json:"weight"`
+}
+
+// NewHTTPRouteSyncer returns a new sync.Interface for reconciling the Gateway
+// API HTTPRoute
+func NewHTTPRouteSyncer(droplet *nginx.Nginx, c dynamic.Interface, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(nginx.NginxHTTPRoute)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(HTTPRouteGroupVersionKind)
+	obj.SetName(droplet.ComponentName(nginx.NginxHTTPRoute))
+	obj.SetNamespace(droplet.Namespace)
+
+	return syncer.NewUnstructuredSyncer("HTTPRoute", droplet.Unwrap(), obj, HTTPRouteResource, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*unstructured.Unstructured)
+		out.SetLabels(labels.Merge(labels.Merge(out.GetLabels(), objLabels), common.ControllerLabels))
+
+		if droplet.Spec.RoutingSpec.GatewaySpec == nil {
+			return fmt.Errorf(".spec.routing.gateway is not defined")
+		}
+		gateway := droplet.Spec.RoutingSpec.GatewaySpec
+
+		spec := httpRouteSpec{
+			ParentRefs: []httpParentRef{
+				{
+					Group:       HTTPRouteGroupVersionKind.Group,
+					Kind:        "Gateway",
+					Name:        gateway.ParentRef.Name,
+					Namespace:   gateway.ParentRef.Namespace,
+					SectionName: gateway.ParentRef.SectionName,
+				},
+			},
+			Rules: []httpRouteRule{
+				{
+					Matches: []httpRouteMatch{
+						{
+							Path: httpPathMatch{Type: "PathPrefix", Value: "/"},
+						},
+					},
+					Filters: gateway.Filters,
+					BackendRefs: []httpBackendRef{
+						{
+							Kind:   "Service",
+							Name:   droplet.FrontendServiceName(),
+							Port:   80,
+							Weight: 1,
+						},
+					},
+				},
+			},
+		}
+		for _, d := range droplet.Spec.AllDomains() {
+			spec.Hostnames = append(spec.Hostnames, string(d))
+		}
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+		if err != nil {
+			return err
+		}
+
+		return unstructured.SetNestedField(out.Object, content, "spec")
+	})
+}
@@ -0,0 +1,170 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"context"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/nginx"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var _ = ginkgo.Describe("HTTPRoute syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		client  *fake.FakeDynamicClient
+		droplet *nginx.Nginx
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		client = fake.NewSimpleDynamicClient(scheme)
+
+		droplet = nginx.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+				UID:       "site-uid",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com", "www.example.com"},
+				Sites: []drupalv1beta1.SiteSpec{{
+					Name:    "blog",
+					Domains: []drupalv1beta1.Domain{"blog.example.com"},
+				}},
+				Topology: drupalv1beta1.SplitTopology,
+				RoutingSpec: drupalv1beta1.RoutingSpec{
+					Mode: drupalv1beta1.GatewayRoutingMode,
+					GatewaySpec: &drupalv1beta1.GatewayRoutingSpec{
+						ParentRef: drupalv1beta1.GatewayParentRef{
+							Name:        "public",
+							Namespace:   "gateways",
+							SectionName: "https",
+						},
+					},
+				},
+			},
+		})
+	})
+
+	getHTTPRoute := func() *unstructured.Unstructured {
+		obj, err := client.Resource(HTTPRouteResource).Namespace("default").Get(context.TODO(), "site", metav1.GetOptions{})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		return obj
+	}
+
+	rule := func(obj *unstructured.Unstructured) map[string]interface{} {
+		rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+		gomega.Expect(rules).To(gomega.HaveLen(1))
+		return rules[0].(map[string]interface{})
+	}
+
+	ginkgo.It("creates a route attached to the gateway for every domain", func() {
+		gomega.Expect(syncer.Sync(context.TODO(), NewHTTPRouteSyncer(droplet, client, scheme), nil)).To(gomega.Succeed())
+
+		obj := getHTTPRoute()
+		gomega.Expect(obj.GetKind()).To(gomega.Equal("HTTPRoute"))
+		gomega.Expect(obj.GetOwnerReferences()).To(gomega.HaveLen(1))
+
+		hostnames, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "hostnames")
+		gomega.Expect(hostnames).To(gomega.Equal([]string{"example.com", "www.example.com", "blog.example.com"}))
+
+		parentRefs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+		gomega.Expect(parentRefs).To(gomega.Equal([]interface{}{
+			map[string]interface{}{
+				"group":       "gateway.networking.k8s.io",
+				"kind":        "Gateway",
+				"name":        "public",
+				"namespace":   "gateways",
+				"sectionName": "https",
+			},
+		}))
+
+		r := rule(obj)
+		gomega.Expect(r).NotTo(gomega.HaveKey("filters"))
+		gomega.Expect(r["matches"]).To(gomega.Equal([]interface{}{
+			map[string]interface{}{
+				"path": map[string]interface{}{"type": "PathPrefix", "value": "/"},
+			},
+		}))
+		gomega.Expect(r["backendRefs"]).To(gomega.Equal([]interface{}{
+			map[string]interface{}{
+				"group":  "",
+				"kind":   "Service",
+				"name":   "site-nginx",
+				"port":   int64(80),
+				"weight": int64(1),
+			},
+		}))
+	})
+
+	ginkgo.It("routes to Varnish while it is enabled", func() {
+		droplet.Spec.Nginx.CacheSpec = &drupalv1beta1.PageCacheSpec{Varnish: &drupalv1beta1.VarnishSpec{}}
+		gomega.Expect(syncer.Sync(context.TODO(), NewHTTPRouteSyncer(droplet, client, scheme), nil)).To(gomega.Succeed())
+
+		name, _, _ := unstructured.NestedString(rule(getHTTPRoute())["backendRefs"].([]interface{})[0].(map[string]interface{}), "name")
+		gomega.Expect(name).To(gomega.Equal("site-varnish"))
+	})
+
+	ginkgo.It("applies the filters", func() {
+		droplet.Spec.RoutingSpec.GatewaySpec.Filters = []drupalv1beta1.HTTPRouteFilter{{
+			Type: drupalv1beta1.RequestHeaderModifierFilter,
+			RequestHeaderModifier: &drupalv1beta1.HTTPHeaderFilter{
+				Set: []drupalv1beta1.HTTPHeader{{Name: "X-Site", Value: "example"}},
+			},
+		}}
+		gomega.Expect(syncer.Sync(context.TODO(), NewHTTPRouteSyncer(droplet, client, scheme), nil)).To(gomega.Succeed())
+
+		gomega.Expect(rule(getHTTPRoute())["filters"]).To(gomega.Equal([]interface{}{
+			map[string]interface{}{
+				"type": "RequestHeaderModifier",
+				"requestHeaderModifier": map[string]interface{}{
+					"set": []interface{}{
+						map[string]interface{}{"name": "X-Site", "value": "example"},
+					},
+				},
+			},
+		}))
+	})
+
+	ginkgo.It("updates the hostnames when domains change", func() {
+		gomega.Expect(syncer.Sync(context.TODO(), NewHTTPRouteSyncer(droplet, client, scheme), nil)).To(gomega.Succeed())
+
+		droplet.Spec.Domains = []drupalv1beta1.Domain{"example.org"}
+		droplet.Spec.Sites = nil
+		gomega.Expect(syncer.Sync(context.TODO(), NewHTTPRouteSyncer(droplet, client, scheme), nil)).To(gomega.Succeed())
+
+		hostnames, _, _ := unstructured.NestedStringSlice(getHTTPRoute().Object, "spec", "hostnames")
+		gomega.Expect(hostnames).To(gomega.Equal([]string{"example.org"}))
+	})
+
+	ginkgo.It("fails without a gateway spec", func() {
+		droplet.Spec.RoutingSpec.GatewaySpec = nil
+		s := NewHTTPRouteSyncer(droplet, client, scheme).(*syncer.UnstructuredSyncer)
+		gomega.Expect(s.SyncFn(&unstructured.Unstructured{Object: map[string]interface{}{}})).To(gomega.MatchError(".spec.routing.gateway is not defined"))
+	})
+})
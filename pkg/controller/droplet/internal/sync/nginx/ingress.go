@@ -16,11 +16,10 @@ limitations under the License.
 package sync
 
 import (
-	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
@@ -28,11 +27,52 @@ import (
 	"github.com/sylus/drupal-operator/pkg/util/syncer"
 )
 
+var (
+	defaultPathType = networkingv1.PathTypePrefix
+)
+
+// ingressPaths returns the paths routed for every domain of the site, extra
+// paths first
+func ingressPaths(droplet *nginx.Nginx) []networkingv1.HTTPIngressPath {
+	path := "/"
+	pathType := &defaultPathType
+	out := []networkingv1.HTTPIngressPath{}
+
+	if spec := droplet.Spec.IngressSpec; spec != nil {
+		for _, p := range spec.ExtraPaths {
+			out = append(out, networkingv1.HTTPIngressPath{
+				Path:     p.Path,
+				PathType: p.PathType,
+				Backend:  p.Backend,
+			})
+		}
+		if len(spec.Path) > 0 {
+			path = spec.Path
+		}
+		if spec.PathType != nil {
+			pathType = spec.PathType
+		}
+	}
+
+	return append(out, networkingv1.HTTPIngressPath{
+		Path:     path,
+		PathType: pathType,
+		Backend: networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: droplet.FrontendServiceName(),
+				Port: networkingv1.ServiceBackendPort{
+					Name: "http",
+				},
+			},
+		},
+	})
+}
+
 // NewIngressSyncer returns a new sync.Interface for reconciling Nginx Ingress
 func NewIngressSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Scheme) syncer.Interface {
 	objLabels := droplet.ComponentLabels(nginx.NginxIngress)
 
-	obj := &extv1beta1.Ingress{
+	obj := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      droplet.ComponentName(nginx.NginxIngress),
 			Namespace: droplet.Namespace,
@@ -40,7 +80,7 @@ func NewIngressSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Sch
 	}
 
 	return syncer.NewObjectSyncer("Ingress", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
-		out := existing.(*extv1beta1.Ingress)
+		out := existing.(*networkingv1.Ingress)
 		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
 
 		if len(out.ObjectMeta.Annotations) == 0 {
@@ -50,23 +90,18 @@ func NewIngressSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Sch
 			out.ObjectMeta.Annotations[k] = v
 		}
 
-		bk := extv1beta1.IngressBackend{
-			ServiceName: droplet.ComponentName(nginx.NginxService),
-			ServicePort: intstr.FromString("http"),
-		}
-		bkpaths := []extv1beta1.HTTPIngressPath{
-			{
-				Path:    "/",
-				Backend: bk,
-			},
+		if droplet.Spec.IngressSpec != nil && droplet.Spec.IngressSpec.IngressClassName != nil {
+			out.Spec.IngressClassName = droplet.Spec.IngressSpec.IngressClassName
 		}
 
-		rules := []extv1beta1.IngressRule{}
-		for _, d := range droplet.Spec.Domains {
-			rules = append(rules, extv1beta1.IngressRule{
+		bkpaths := ingressPaths(droplet)
+
+		rules := []networkingv1.IngressRule{}
+		for _, d := range droplet.Spec.AllDomains() {
+			rules = append(rules, networkingv1.IngressRule{
 				Host: string(d),
-				IngressRuleValue: extv1beta1.IngressRuleValue{
-					HTTP: &extv1beta1.HTTPIngressRuleValue{
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
 						Paths: bkpaths,
 					},
 				},
@@ -74,14 +109,14 @@ func NewIngressSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Sch
 		}
 		out.Spec.Rules = rules
 
-		if len(droplet.Spec.TLSSecretRef) > 0 {
-			tls := extv1beta1.IngressTLS{
-				SecretName: string(droplet.Spec.TLSSecretRef),
+		if secretName := droplet.TLSSecretName(); len(secretName) > 0 {
+			tls := networkingv1.IngressTLS{
+				SecretName: secretName,
 			}
-			for _, d := range droplet.Spec.Domains {
+			for _, d := range droplet.Spec.AllDomains() {
 				tls.Hosts = append(tls.Hosts, string(d))
 			}
-			out.Spec.TLS = []extv1beta1.IngressTLS{tls}
+			out.Spec.TLS = []networkingv1.IngressTLS{tls}
 		} else {
 			out.Spec.TLS = nil
 		}
@@ -0,0 +1,174 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync_test
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	"github.com/onsi/gomega"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/nginx"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+var _ = ginkgo.Describe("Ingress syncer", func() {
+	var (
+		scheme  *runtime.Scheme
+		droplet *nginx.Nginx
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		droplet = nginx.New(&drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: drupalv1beta1.DropletSpec{
+				Domains: []drupalv1beta1.Domain{"example.com", "www.example.com"},
+				Sites: []drupalv1beta1.SiteSpec{{
+					Name:    "blog",
+					Domains: []drupalv1beta1.Domain{"blog.example.com"},
+				}},
+				Topology: drupalv1beta1.SplitTopology,
+			},
+		})
+	})
+
+	sync := func(out *networkingv1.Ingress) *networkingv1.Ingress {
+		s := NewIngressSyncer(droplet, nil, scheme).(*syncer.ObjectSyncer)
+		gomega.Expect(s.SyncFn(out)).To(gomega.Succeed())
+		return out
+	}
+
+	hosts := func(ingress *networkingv1.Ingress) []string {
+		out := []string{}
+		for _, rule := range ingress.Spec.Rules {
+			out = append(out, rule.Host)
+		}
+		return out
+	}
+
+	prefix := networkingv1.PathTypePrefix
+	exact := networkingv1.PathTypeExact
+
+	ginkgo.It("routes every domain of every site", func() {
+		out := sync(&networkingv1.Ingress{})
+		gomega.Expect(hosts(out)).To(gomega.Equal([]string{"example.com", "www.example.com", "blog.example.com"}))
+		for _, rule := range out.Spec.Rules {
+			gomega.Expect(rule.HTTP.Paths).To(gomega.Equal([]networkingv1.HTTPIngressPath{{
+				Path:     "/",
+				PathType: &prefix,
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: "site-nginx",
+						Port: networkingv1.ServiceBackendPort{Name: "http"},
+					},
+				},
+			}}))
+		}
+		gomega.Expect(out.Spec.TLS).To(gomega.BeNil())
+	})
+
+	table.DescribeTable("backend service",
+		func(topology drupalv1beta1.Topology, varnish bool, service string) {
+			droplet.Spec.Topology = topology
+			if varnish {
+				droplet.Spec.Nginx.CacheSpec = &drupalv1beta1.PageCacheSpec{Varnish: &drupalv1beta1.VarnishSpec{}}
+			}
+			out := sync(&networkingv1.Ingress{})
+			gomega.Expect(out.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).To(gomega.Equal(service))
+		},
+		table.Entry("split", drupalv1beta1.SplitTopology, false, "site-nginx"),
+		table.Entry("combined", drupalv1beta1.CombinedTopology, false, "site"),
+		table.Entry("varnish", drupalv1beta1.SplitTopology, true, "site-varnish"),
+	)
+
+	ginkgo.It("routes the extra paths before the site path", func() {
+		droplet.Spec.IngressSpec = &drupalv1beta1.IngressSpec{
+			Path:     "/site",
+			PathType: &exact,
+			ExtraPaths: []drupalv1beta1.IngressPath{{
+				Path: "/api",
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: "api",
+						Port: networkingv1.ServiceBackendPort{Number: 8080},
+					},
+				},
+			}},
+		}
+		drupalv1beta1.SetDefaults_DropletSpec(&droplet.Spec)
+
+		paths := sync(&networkingv1.Ingress{}).Spec.Rules[0].HTTP.Paths
+		gomega.Expect(paths).To(gomega.HaveLen(2))
+		gomega.Expect(paths[0].Path).To(gomega.Equal("/api"))
+		gomega.Expect(paths[0].PathType).To(gomega.Equal(&prefix))
+		gomega.Expect(paths[0].Backend.Service.Name).To(gomega.Equal("api"))
+		gomega.Expect(paths[1].Path).To(gomega.Equal("/site"))
+		gomega.Expect(paths[1].PathType).To(gomega.Equal(&exact))
+	})
+
+	ginkgo.It("sets the ingress class and merges annotations", func() {
+		class := "nginx"
+		droplet.Spec.IngressSpec = &drupalv1beta1.IngressSpec{IngressClassName: &class}
+		droplet.Spec.IngressAnnotations = map[string]string{"example.com/owner": "site"}
+
+		out := sync(&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"example.com/other": "kept"},
+			},
+		})
+		gomega.Expect(out.Spec.IngressClassName).To(gomega.Equal(&class))
+		gomega.Expect(out.Annotations).To(gomega.Equal(map[string]string{
+			"example.com/owner": "site",
+			"example.com/other": "kept",
+		}))
+	})
+
+	table.DescribeTable("tls",
+		func(tlsSecretRef drupalv1beta1.SecretRef, tlsSpec *drupalv1beta1.TLSSpec, secretName string) {
+			droplet.Spec.TLSSecretRef = tlsSecretRef
+			droplet.Spec.TLSSpec = tlsSpec
+			out := sync(&networkingv1.Ingress{})
+			gomega.Expect(out.Spec.TLS).To(gomega.Equal([]networkingv1.IngressTLS{{
+				Hosts:      []string{"example.com", "www.example.com", "blog.example.com"},
+				SecretName: secretName,
+			}}))
+		},
+		table.Entry("existing secret", drupalv1beta1.SecretRef("site-cert"), nil, "site-cert"),
+		table.Entry("issued certificate", drupalv1beta1.SecretRef(""), &drupalv1beta1.TLSSpec{
+			IssuerRef: &drupalv1beta1.CertIssuerRef{Name: "letsencrypt"},
+		}, "site-tls"),
+	)
+
+	ginkgo.It("removes tls once disabled", func() {
+		out := &networkingv1.Ingress{
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{{SecretName: "site-cert"}},
+			},
+		}
+		gomega.Expect(sync(out).Spec.TLS).To(gomega.BeNil())
+	})
+})
@@ -0,0 +1,232 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/imdario/mergo"
+
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/templates"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/mergo/transformers"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+const (
+	varnishHTTPPort = 80
+)
+
+// VarnishSettings spec
+type VarnishSettings struct {
+	Backend string
+	Cache   *PageCacheSettings
+	// PurgeACL holds the entries of the purge acl
+	PurgeACL []string
+}
+
+// varnishVCL renders the default.vcl of the Varnish pods
+func varnishVCL(droplet *nginx.Nginx) (string, error) {
+	cache, err := newPageCacheSettings(droplet)
+	if err != nil {
+		return "", err
+	}
+
+	templateInput := VarnishSettings{
+		Backend: droplet.BackendServiceName(),
+		Cache:   cache,
+	}
+	for _, ipNet := range templateInput.Cache.PurgeAllowFrom {
+		ones, _ := ipNet.Mask.Size()
+		templateInput.PurgeACL = append(templateInput.PurgeACL, fmt.Sprintf(`"%s"/%d`, ipNet.IP, ones))
+	}
+
+	return common.GenerateConfig("default.vcl", templateInput, templates.ConfigMapVarnish)
+}
+
+// NewVarnishConfigMapSyncer returns a new sync.Interface for reconciling the
+// Varnish ConfigMap
+func NewVarnishConfigMapSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(nginx.NginxVarnish)
+
+	obj := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.ComponentName(nginx.NginxVarnish),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("VarnishConfigMap", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*corev1.ConfigMap)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		if !droplet.HasVarnish() {
+			return fmt.Errorf(".spec.nginx.cache.varnish is not defined")
+		}
+
+		vcl, err := varnishVCL(droplet)
+		if err != nil {
+			return err
+		}
+		out.Data = map[string]string{
+			"default.vcl": vcl,
+		}
+
+		return nil
+	})
+}
+
+// NewVarnishDeploymentSyncer returns a new sync.Interface for reconciling the
+// Varnish Deployment
+func NewVarnishDeploymentSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(nginx.NginxVarnish)
+
+	obj := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.ComponentName(nginx.NginxVarnish),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("VarnishDeployment", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*appsv1.Deployment)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		if !droplet.HasVarnish() {
+			return fmt.Errorf(".spec.nginx.cache.varnish is not defined")
+		}
+		cache := droplet.Spec.Nginx.CacheSpec
+
+		selector := metav1.SetAsLabelSelector(droplet.VarnishPodLabels())
+		if !reflect.DeepEqual(selector, out.Spec.Selector) {
+			if out.ObjectMeta.CreationTimestamp.IsZero() {
+				out.Spec.Selector = selector
+			} else {
+				return fmt.Errorf("deployment selector is immutable")
+			}
+		}
+
+		// varnish only loads default.vcl on start, roll the pods when it
+		// changes
+		vcl, err := varnishVCL(droplet)
+		if err != nil {
+			return err
+		}
+		out.Spec.Template.ObjectMeta.Labels = droplet.VarnishPodLabels()
+		out.Spec.Template.ObjectMeta.Annotations = map[string]string{
+			"drupal.sylus.ca/vclChecksum": fmt.Sprintf("%x", sha256.Sum256([]byte(vcl))),
+		}
+
+		spec := corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "varnish",
+					Image: cache.Varnish.Image,
+					Command: []string{
+						"varnishd", "-F",
+						"-a", fmt.Sprintf(":%d", varnishHTTPPort),
+						"-f", "/etc/varnish/default.vcl",
+						"-s", fmt.Sprintf("malloc,%dm", cache.Size.Value()/(1024*1024)),
+						"-t", fmt.Sprintf("%d", int64(cache.TTL.Seconds())),
+					},
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "http",
+							ContainerPort: varnishHTTPPort,
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "vcl",
+							MountPath: "/etc/varnish",
+							ReadOnly:  true,
+						},
+					},
+					Resources: cache.Varnish.Resources,
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "vcl",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: droplet.ComponentName(nginx.NginxVarnish),
+							},
+						},
+					},
+				},
+			},
+		}
+
+		err = mergo.Merge(&out.Spec.Template.Spec, spec, mergo.WithTransformers(transformers.PodSpec))
+		if err != nil {
+			return err
+		}
+
+		out.Spec.Replicas = cache.Varnish.Replicas
+
+		return nil
+	})
+}
+
+// NewVarnishServiceSyncer returns a new sync.Interface for reconciling the
+// Varnish Service
+func NewVarnishServiceSyncer(droplet *nginx.Nginx, c client.Client, scheme *runtime.Scheme) syncer.Interface {
+	objLabels := droplet.ComponentLabels(nginx.NginxVarnish)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      droplet.ComponentName(nginx.NginxVarnish),
+			Namespace: droplet.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("VarnishService", droplet.Unwrap(), obj, c, scheme, func(existing runtime.Object) error {
+		out := existing.(*corev1.Service)
+		out.Labels = labels.Merge(labels.Merge(out.Labels, objLabels), common.ControllerLabels)
+
+		selector := droplet.VarnishPodLabels()
+		if !labels.Equals(selector, out.Spec.Selector) {
+			if out.ObjectMeta.CreationTimestamp.IsZero() {
+				out.Spec.Selector = selector
+			} else {
+				return fmt.Errorf("service selector is immutable")
+			}
+		}
+
+		if len(out.Spec.Ports) != 1 {
+			out.Spec.Ports = make([]corev1.ServicePort, 1)
+		}
+
+		out.Spec.Ports[0].Name = "http"
+		out.Spec.Ports[0].Port = int32(80)
+		out.Spec.Ports[0].TargetPort = intstr.FromInt(varnishHTTPPort)
+
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package templates
+
+// ConfigMapVarnish default.vcl file
+var ConfigMapVarnish = `
+vcl 4.0;
+
+backend default {
+	.host = "[[ .Backend ]]";
+	.port = "80";
+}
+[[- if .Cache.PurgeAllowFrom ]]
+
+acl purge {
+	[[- range .PurgeACL ]]
+	[[ . ]];
+	[[- end ]]
+}
+[[- end ]]
+
+sub vcl_recv {
+	[[- if .Cache.PurgeAllowFrom ]]
+	if (req.method == "PURGE" || req.method == "BAN") {
+		# requests through the ingress carry the address of their client
+		if (!client.ip ~ purge || req.http.X-Forwarded-For ~ ",") {
+			return (synth(403, "Forbidden"));
+		}
+		if (req.method == "PURGE") {
+			return (purge);
+		}
+		if (req.http.Cache-Tags) {
+			ban("obj.http.Cache-Tags ~ " + req.http.Cache-Tags);
+		} else {
+			ban("obj.http.X-Host == " + req.http.host + " && obj.http.X-Url ~ " + req.url);
+		}
+		return (synth(200, "Banned"));
+	}
+	[[- end ]]
+
+	if (req.method != "GET" && req.method != "HEAD") {
+		return (pass);
+	}
+	[[- if .Cache.BypassCookies ]]
+
+	# Requests of logged-in users bypass the cache.
+	if (req.http.Cookie ~ "[[ .Cache.BypassCookies ]]") {
+		return (pass);
+	}
+	[[- end ]]
+
+	unset req.http.Cookie;
+	return (hash);
+}
+
+sub vcl_hash {
+	# Redirects to https must not be served to https requests.
+	hash_data(req.http.X-Forwarded-Proto);
+}
+
+sub vcl_backend_response {
+	# Kept for banning by url with the ban lurker.
+	set beresp.http.X-Host = bereq.http.host;
+	set beresp.http.X-Url = bereq.url;
+
+	if (beresp.status == 404) {
+		set beresp.ttl = [[ .Cache.NotFoundTTL ]]s;
+	}
+}
+
+sub vcl_deliver {
+	unset resp.http.X-Host;
+	unset resp.http.X-Url;
+	unset resp.http.Cache-Tags;
+
+	if (obj.hits > 0) {
+		set resp.http.X-Varnish-Cache = "HIT";
+	} else {
+		set resp.http.X-Varnish-Cache = "MISS";
+	}
+}
+`
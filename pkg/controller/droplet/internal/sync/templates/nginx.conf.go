@@ -28,26 +28,78 @@ http {
 	include /etc/nginx/mime.types;
 	index index.html index.htm;
 	keepalive_timeout 240;
-	proxy_cache_path /var/cache/nginx levels=1:2 keys_zone=one:8m max_size=3000m inactive=600m;
+	[[- with .Cache ]]
+	fastcgi_cache_path [[ .Path ]] levels=1:2 keys_zone=drupal:16m max_size=[[ .Size ]]m inactive=[[ .TTL ]]s;
+	fastcgi_cache_key "$scheme$request_method$host$request_uri";
+	[[- end ]]
 	proxy_temp_path /var/tmp;
 	sendfile on;
 	server_tokens off;
 	tcp_nopush on;
 	types_hash_max_size 2048;
 
+	[[- with .Cache ]]
+
+	# Requests of logged-in users bypass the page cache.
+	map $http_cookie $no_cache {
+		default 0;
+		[[- if .BypassCookies ]]
+		"~[[ .BypassCookies ]]" 1;
+		[[- end ]]
+	}
+	[[- if .PurgeAllowFrom ]]
+
+	# The request URI of the page purged by a request to /purge/<path>.
+	map $request_uri $purge_request_uri {
+		"~^/purge(?<purge_path>/.*)$" $purge_path;
+	}
+	[[- end ]]
+	[[- end ]]
+
+	# The scheme of the request, as seen by the proxy in front of nginx.
+	map $http_x_forwarded_proto $forwarded_scheme {
+		default $http_x_forwarded_proto;
+		"" $scheme;
+	}
+	[[- if .ForceHTTPS ]]
+
+	# Domains which only get served over https.
+	map $host $force_https {
+		default 0;
+		[[- range .ForceHTTPS ]]
+		[[ . ]] 1;
+		[[- end ]]
+	}
+	[[- end ]]
+	[[- range .Redirects ]]
+
 	server {
-			#IPv4
 			listen 80;
+			listen [::]:80;
+			server_name [[ .Domain ]];
+			return 301 [[ .Scheme ]]://[[ .Target ]]$request_uri;
+	}
+	[[- end ]]
+
+	server {
+			#IPv4
+			listen 80 default_server;
 
 			#IPv6
-			listen [::]:80;
+			listen [::]:80 default_server;
 
 			# Filesystem root of the site and index with fallback.
 			root /var/www/html;
 			index index.php index.html index.htm;
 
 			# Make site accessible from http://domain;
-			server_name drupal.innovation.cloud.statcan.ca;
+			server_name[[ range .Domains ]] [[ . ]][[ else ]] _[[ end ]];
+			[[- if .ForceHTTPS ]]
+
+			if ($force_https$forwarded_scheme = "1http") {
+				return 301 https://$host$request_uri;
+			}
+			[[- end ]]
 
 			location / {
 					# First attempt to serve request as file, then
@@ -64,26 +116,78 @@ http {
 				fastcgi_param SCRIPT_FILENAME $request_filename;
 				fastcgi_intercept_errors on;
 				fastcgi_pass [[ .Host ]]:9000;
+				[[- with .Cache ]]
+
+				fastcgi_cache drupal;
+				fastcgi_cache_valid 200 301 302 [[ .TTL ]]s;
+				fastcgi_cache_valid 404 [[ .NotFoundTTL ]]s;
+				fastcgi_cache_bypass $no_cache;
+				fastcgi_no_cache $no_cache;
+				fastcgi_cache_lock on;
+				fastcgi_cache_use_stale error timeout updating http_500 http_503;
+				add_header X-Cache-Status $upstream_cache_status;
+				[[- end ]]
+			}
+			[[- with .Cache ]][[ if .PurgeAllowFrom ]]
+
+			# Purges the cached page at the path following /purge, this
+			# requires the ngx_cache_purge module.
+			location ^~ /purge/ {
+				# requests through the ingress carry the address of their client
+				if ($http_x_forwarded_for) {
+					return 403;
+				}
+				[[- range .PurgeAllowFrom ]]
+				allow [[ . ]];
+				[[- end ]]
+				deny all;
+				fastcgi_cache_purge drupal "${scheme}GET$host$purge_request_uri";
 			}
+			[[- end ]][[ end ]]
 
 			location ~ (^/s3/files/styles/|^/sites/.*/files/imagecache/|^/sites/.*/files/styles/) {
 				expires max;
 				try_files $uri @rewrite;
 			}
 
+			location @rewrite {
+				rewrite ^ /index.php;
+			}
+
+			[[- if .MediaPath ]]
+
+			location ^~ [[ .MediaPath ]] {
+				expires 30d;
+				try_files $uri @rewrite;
+			}
+			[[- else if .MediaBaseURL ]]
+
 			location ~* ^/(s3fs-css|s3fs-js|sites/default/files)/(.*) {
-				set $s3_base_path "stcdrupal.blob.core.windows.net/drupal-public";
+				set $media_base_url "[[ .MediaBaseURL ]]";
 				set $file_path $2;
 
-				resolver 10.0.0.10 valid=5s ipv6=off;
+				resolver [[ .Resolver ]] valid=5s ipv6=off;
 				resolver_timeout 5s;
 
-				proxy_pass https://$s3_base_path/$file_path;
+				proxy_pass $media_base_url/$file_path;
 			}
+			[[- end ]]
 
 			location ~ /\.ht {
 				deny all;
 			}
 	}
+	[[- if .StubStatusPort ]]
+
+	# Connection metrics, scraped by the nginx exporter sidecar
+	server {
+		listen 127.0.0.1:[[ .StubStatusPort ]];
+
+		location = /stub_status {
+			stub_status;
+			access_log off;
+		}
+	}
+	[[- end ]]
 }
 `
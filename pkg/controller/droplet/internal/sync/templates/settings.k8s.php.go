@@ -0,0 +1,224 @@
+package templates
+
+// ConfigMapSettingsK8s settings.k8s.php file holding the settings owned by the
+// operator, included from the settings.php of the site
+var ConfigMapSettingsK8s = `<?php
+
+/**
+ * @file
+ * Settings generated by the drupal-operator.
+ *
+ * Include this file from the settings.php of the site:
+ * @code
+ * if (file_exists($app_root . '/' . $site_path . '/settings.k8s.php')) {
+ *   include $app_root . '/' . $site_path . '/settings.k8s.php';
+ * }
+ * @endcode
+ */
+
+/**
+ * Database settings.
+ */
+$databases['default']['default'] = array (
+  'database' => [[ php .Name ]],
+  'username' => [[ php .User ]],
+  'password' => [[ php .Pass ]],
+  'prefix' => '',
+  'host' => [[ php .Host ]],
+  'port' => [[ php .Port ]],
+  'namespace' => [[ php .Namespace ]],
+  'driver' => [[ php .Driver ]],
+);
+
+/**
+ * Get environment settings.
+ *
+ * Production (default): normal production settings.
+ * Development: Use development settings.
+ */
+$drupal_settings = 'production';
+if (isset($_ENV['DRUPAL_SETTINGS'])) {
+  $drupal_settings = $_ENV['DRUPAL_SETTINGS'];
+}
+
+/**
+ * Trust the domains of the site.
+ */
+$settings['trusted_host_patterns'] = array(
+[[- range .TrustedHostPatterns ]]
+  [[ php . ]],
+[[- end ]]
+);
+
+/** Allow any host outside of production, eg. for port-forwarded requests */
+if ($drupal_settings !== 'production') {
+  $settings['trusted_host_patterns'][] = '[\s\S]*';
+}
+[[- if .ReverseProxyAddresses ]]
+
+/**
+ * Trust the X-Forwarded-* headers set by the proxies in front of Drupal.
+ */
+$settings['reverse_proxy'] = TRUE;
+$settings['reverse_proxy_addresses'] = array(
+[[- range .ReverseProxyAddresses ]]
+  [[ php . ]],
+[[- end ]]
+);
+[[- end ]]
+[[- if .PrivateFilesPath ]]
+
+/**
+ * Set private file path directory.
+ */
+$settings['file_private_path'] =  [[ php .PrivateFilesPath ]];
+[[- end ]]
+[[- if .FilesPath ]]
+
+/**
+ * Set public file path directory.
+ */
+$settings['file_public_path'] = [[ php .FilesPath ]];
+[[- end ]]
+[[- with .Media ]][[ if eq .Module "s3fs" ]]
+
+/**
+ * Store public files in object storage using the s3fs module.
+ */
+[[- template "s3fs" . ]]
+$settings['s3fs.use_s3_for_public'] = TRUE;
+[[- else ]]
+
+/**
+ * Store public files in object storage using the flysystem module.
+ */
+$settings['flysystem']['[[ .Driver ]]'] = array(
+[[- template "flysystem" . ]]
+  'serve_js' => TRUE,
+  'serve_css' => TRUE,
+);
+[[- end ]][[ end ]]
+[[- with .PrivateFiles ]][[ if eq .Module "s3fs" ]]
+
+/**
+ * Store private files in object storage using the s3fs module. The s3fs
+ * module shares a single bucket between public and private files.
+ */
+[[- if not .SharedBucket ]]
+[[- template "s3fs" . ]]
+[[- end ]]
+$config['s3fs.settings']['private_folder'] = [[ php .Folder ]];
+$settings['s3fs.use_s3_for_private'] = TRUE;
+[[- else ]]
+
+/**
+ * Store private files in object storage using the flysystem module, which
+ * provides the private:// scheme as file_private_path is left unset.
+ */
+$settings['flysystem']['private'] = array(
+[[- template "flysystem" . ]]
+  'public' => FALSE,
+);
+[[- end ]][[ end ]]
+[[- with .Cache ]][[ if eq .Backend "redis" ]]
+
+/**
+ * Use redis as the default cache backend.
+ */
+if (extension_loaded('redis')) {
+  $settings['redis.connection']['interface'] = 'PhpRedis';
+  $settings['redis.connection']['host'] = [[ php .Host ]];
+  $settings['redis.connection']['port'] = [[ .Port ]];
+  $settings['cache_prefix'] = [[ php .Prefix ]];
+[[- if .HasPassword ]]
+  $settings['redis.connection']['password'] = getenv('CACHE_PASSWORD');
+[[- end ]]
+  $settings['cache']['default'] = 'cache.backend.redis';
+  $settings['container_yamls'][] = 'modules/contrib/redis/example.services.yml';
+}
+[[- else ]]
+
+/**
+ * Use memcache as the default cache backend.
+ */
+if (extension_loaded('memcached')) {
+  $settings['memcache']['servers'] = array([[ php (printf "%s:%d" .Host .Port) ]] => 'default');
+  $settings['memcache']['bins'] = array('default' => 'default');
+  $settings['memcache']['key_prefix'] = [[ php .Prefix ]];
+  $settings['cache']['default'] = 'cache.backend.memcache';
+}
+[[- end ]][[ end ]]
+[[- with .Search ]]
+
+/**
+ * Point the search_api server at the Solr core.
+ */
+$solr_connector = &$config['search_api.server.[[ .ServerID ]]']['backend_config']['connector_config'];
+$solr_connector['scheme'] = [[ php .Scheme ]];
+$solr_connector['host'] = [[ php .Host ]];
+$solr_connector['port'] = [[ .Port ]];
+$solr_connector['path'] = [[ php .Path ]];
+$solr_connector['core'] = [[ php .Core ]];
+unset($solr_connector);
+[[- end ]]
+[[- if .Overrides ]]
+
+/**
+ * Overrides from spec.drupal.settings.
+ */
+[[- range .Overrides ]]
+[[ . ]]
+[[- end ]]
+[[- end ]]
+[[- range .PHP ]]
+
+[[ . ]]
+[[- end ]]
+[[- range .SettingsFrom ]]
+
+/** Include the snippet from spec.drupal.settingsFrom */
+if (file_exists([[ php . ]])) {
+  include [[ php . ]];
+}
+[[- end ]]
+[[- define "s3fs" ]]
+$config['s3fs.settings']['bucket'] = [[ php .Bucket ]];
+$config['s3fs.settings']['region'] = [[ php .Region ]];
+$config['s3fs.settings']['root_folder'] = [[ php .Prefix ]];
+[[- if .Endpoint ]]
+$config['s3fs.settings']['use_customhost'] = TRUE;
+$config['s3fs.settings']['hostname'] = [[ php .Endpoint ]];
+$config['s3fs.settings']['use_path_style_endpoint'] = TRUE;
+[[- end ]]
+$settings['s3fs.access_key'] = getenv('[[ .EnvPrefix ]]AWS_ACCESS_KEY_ID');
+$settings['s3fs.secret_key'] = getenv('[[ .EnvPrefix ]]AWS_SECRET_ACCESS_KEY');
+[[- end ]]
+[[- define "flysystem" ]]
+  'driver' => '[[ .Driver ]]',
+  'config' => array(
+[[- if eq .Driver "azure" ]]
+    'name' => [[ php .Account ]],
+    'key' => getenv('[[ .EnvPrefix ]]AZURE_STORAGE_KEY'),
+    'container' => [[ php .Bucket ]],
+    'prefix' => [[ php .Prefix ]],
+    'endpointSuffix' => 'core.windows.net',
+    'protocol' => 'https',
+[[- else ]]
+    'bucket' => [[ php .Bucket ]],
+    'prefix' => [[ php .Prefix ]],
+[[- end ]]
+[[- if eq .Driver "s3" ]]
+    'key' => getenv('[[ .EnvPrefix ]]AWS_ACCESS_KEY_ID'),
+    'secret' => getenv('[[ .EnvPrefix ]]AWS_SECRET_ACCESS_KEY'),
+    'region' => [[ php .Region ]],
+[[- if .Endpoint ]]
+    'endpoint' => [[ php .Endpoint ]],
+[[- end ]]
+[[- end ]]
+[[- if eq .Driver "gcs" ]]
+    'keyFilePath' => getenv('[[ .EnvPrefix ]]GOOGLE_APPLICATION_CREDENTIALS'),
+[[- end ]]
+  ),
+  'cache' => TRUE,
+[[- end ]]
+`
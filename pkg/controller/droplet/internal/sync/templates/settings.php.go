@@ -1,6 +1,7 @@
 package templates
 
-// ConfigMapSettings Drupal settings.php file
+// ConfigMapSettings Drupal settings.php file, mounted over the settings.php
+// of the site in override mode. It includes the generated settings.k8s.php.
 //nolint
 var ConfigMapSettings = `<?php
 
@@ -90,16 +91,6 @@ var ConfigMapSettings = `<?php
  * );
  * @endcode
  */
- $databases['default']['default'] = array (
-  'database' => '[[ .Name ]]',
-  'username' => '[[ .User ]]',
-  'password' => '[[ .Pass ]]',
-  'prefix' => '',
-  'host' => '[[ .Host ]]',
-  'port' => '[[ .Port ]]',
-  'namespace' => '[[ .Namespace ]]',
-  'driver' => '[[ .Driver ]]',
-);
 
 /**
  * Customizing database settings.
@@ -718,25 +709,9 @@ $settings['container_yamls'][] = __DIR__ . '/services.yml';
  */
 
 /**
- * Get environment settings.
- *
- * Production (default): normal production settings.
- * Development: Use development settings.
- */
-$drupal_settings = 'production';
-if (isset($_ENV['DRUPAL_SETTINGS'])) {
-  $drupal_settings = $_ENV['DRUPAL_SETTINGS'];
-}
-
-/** Todo: create better patterns on production sites */
-if ($drupal_settings !== 'production') {
-  $settings['trusted_host_patterns'] = array('[\s\S]*');
-}
-
-/**
- * Set private file path directory.
+ * Load the settings generated by the drupal-operator.
  */
-$settings['file_private_path'] =  '/var/www/files_private';
+include __DIR__ . '/settings.k8s.php';
 
 /**
  * Load local development override configuration, if available.
@@ -0,0 +1,19 @@
+package templates
+
+// ConfigMapSites Drupal sites.php file mapping the domains of a multisite
+// to their site directories
+var ConfigMapSites = `<?php
+
+/**
+ * @file
+ * Configuration file for multi-site support and directory aliasing feature.
+ *
+ * Generated by the drupal-operator from the sites of the droplet.
+ */
+
+$sites = array(
+[[- range .Entries ]]
+  [[ php .Domain ]] => [[ php .Directory ]],
+[[- end ]]
+);
+`
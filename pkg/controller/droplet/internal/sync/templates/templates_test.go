@@ -0,0 +1,239 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templates_test
+
+import (
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/common"
+	syncDrupal "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/drupal"
+	syncNginx "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/nginx"
+	. "github.com/sylus/drupal-operator/pkg/controller/droplet/internal/sync/templates"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/internal/nginx"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+// render runs the sync function of a ConfigMap syncer and returns the
+// rendered files
+func render(s syncer.Interface) map[string]string {
+	out := &corev1.ConfigMap{}
+	gomega.Expect(s.(*syncer.ObjectSyncer).SyncFn(out)).To(gomega.Succeed())
+	gomega.Expect(out.Data).NotTo(gomega.BeEmpty())
+	for name, file := range out.Data {
+		// a field missing from the template input renders as <no value>
+		gomega.Expect(file).NotTo(gomega.ContainSubstring("<no value>"), name)
+	}
+	return out.Data
+}
+
+var _ = ginkgo.Describe("Config templates", func() {
+	var (
+		scheme *runtime.Scheme
+		spec   drupalv1beta1.DropletSpec
+	)
+
+	newDroplet := func() *drupalv1beta1.Droplet {
+		obj := &drupalv1beta1.Droplet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "site",
+				Namespace: "default",
+			},
+			Spec: *spec.DeepCopy(),
+		}
+		drupalv1beta1.SetDefaults_DropletSpec(&obj.Spec)
+		return obj
+	}
+
+	renderDrupal := func() map[string]string {
+		droplet := drupal.New(newDroplet())
+		droplet.SetDefaults()
+		return render(syncDrupal.NewConfigMapSyncer(droplet, nil, scheme))
+	}
+
+	renderNginx := func() map[string]string {
+		droplet := nginx.New(newDroplet())
+		droplet.SetDefaults()
+		return render(syncNginx.NewConfigMapSyncer(droplet, nil, scheme))
+	}
+
+	renderVarnish := func() map[string]string {
+		droplet := nginx.New(newDroplet())
+		droplet.SetDefaults()
+		return render(syncNginx.NewVarnishConfigMapSyncer(droplet, nil, scheme))
+	}
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(drupalv1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		spec = drupalv1beta1.DropletSpec{
+			Domains: []drupalv1beta1.Domain{"example.com"},
+		}
+	})
+
+	ginkgo.When("the spec is minimal", func() {
+		ginkgo.It("renders settings.k8s.php", func() {
+			data := renderDrupal()
+			gomega.Expect(data).To(gomega.HaveLen(1))
+			gomega.Expect(data["settings.k8s.php"]).To(gomega.ContainSubstring("  '^example\\\\.com$',\n"))
+		})
+
+		ginkgo.It("renders nginx.conf", func() {
+			conf := renderNginx()["nginx.conf"]
+			gomega.Expect(conf).To(gomega.ContainSubstring("server_name example.com;"))
+			gomega.Expect(conf).NotTo(gomega.ContainSubstring("fastcgi_cache drupal;"))
+			gomega.Expect(conf).NotTo(gomega.ContainSubstring("stub_status"))
+		})
+	})
+
+	ginkgo.When("every feature is enabled", func() {
+		ginkgo.BeforeEach(func() {
+			salt := `salt'with\quotes`
+			spec.Domains = append(spec.Domains, "www.example.com", "old.example.com")
+			spec.DomainOptions = []drupalv1beta1.DomainOptions{
+				{Domain: "example.com", ForceHTTPS: true},
+				{Domain: "www.example.com", WWW: drupalv1beta1.RemoveWWW},
+				{Domain: "old.example.com", RedirectTo: "main", ForceHTTPS: true},
+			}
+			spec.Sites = []drupalv1beta1.SiteSpec{
+				{Name: "blog", Domains: []drupalv1beta1.Domain{"blog.example.com"}, InstallProfile: "minimal"},
+			}
+			spec.ReverseProxyAddresses = []string{"10.0.0.1"}
+			spec.TLSSpec = &drupalv1beta1.TLSSpec{
+				IssuerRef: &drupalv1beta1.CertIssuerRef{Name: "letsencrypt"},
+			}
+			spec.CacheSpec = &drupalv1beta1.CacheSpec{
+				Backend: drupalv1beta1.RedisCacheBackend,
+				External: &drupalv1beta1.ExternalCacheSpec{
+					Host:              "redis.example.com",
+					PasswordSecretRef: "redis",
+				},
+			}
+			spec.SearchSpec = &drupalv1beta1.SearchSpec{}
+			spec.Drupal.MediaVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+				S3VolumeSource: &drupalv1beta1.S3VolumeSource{
+					Bucket:     "media",
+					PathPrefix: "site/",
+					Region:     "ca-central-1",
+					Endpoint:   "https://minio.example.com",
+				},
+			}
+			spec.Drupal.PrivateFilesVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+				GCSVolumeSource: &drupalv1beta1.GCSVolumeSource{Bucket: "private"},
+			}
+			spec.Drupal.SettingsSpec = &drupalv1beta1.SettingsSpec{
+				Settings: []drupalv1beta1.SettingOverride{{Keys: []string{"hash_salt"}, String: &salt}},
+				PHP:      []string{"$settings['extension_discovery_scan_tests'] = FALSE;"},
+			}
+			spec.Drupal.SettingsFrom = []drupalv1beta1.SettingsFromSource{
+				{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "settings.php"}},
+			}
+			spec.Nginx.CacheSpec = &drupalv1beta1.PageCacheSpec{
+				Purge:   &drupalv1beta1.PageCachePurgeSpec{},
+				Varnish: &drupalv1beta1.VarnishSpec{},
+			}
+			spec.MonitoringSpec = &drupalv1beta1.MonitoringSpec{Enabled: true}
+		})
+
+		ginkgo.It("renders the settings of every site", func() {
+			data := renderDrupal()
+			gomega.Expect(data).To(gomega.HaveKey("sites.php"))
+			gomega.Expect(data["sites.php"]).To(gomega.ContainSubstring("'blog.example.com' => 'blog',"))
+			gomega.Expect(data).To(gomega.HaveKey(drupal.FPMStatusConfigKey))
+			gomega.Expect(data[drupal.FPMStatusConfigKey]).To(gomega.ContainSubstring("pm.status_path = /status"))
+
+			settings := data["settings.k8s.php"]
+			gomega.Expect(settings).To(gomega.ContainSubstring(`$settings['hash_salt'] = 'salt\'with\\quotes';`))
+			gomega.Expect(settings).To(gomega.ContainSubstring("$settings['reverse_proxy'] = TRUE;"))
+			gomega.Expect(settings).To(gomega.ContainSubstring("$settings['redis.connection']['host'] = 'redis.example.com';"))
+			gomega.Expect(settings).To(gomega.ContainSubstring("$config['s3fs.settings']['root_folder'] = 'site/';"))
+
+			blog := data["blog.settings.k8s.php"]
+			gomega.Expect(blog).To(gomega.ContainSubstring("  'database' => 'blog',"))
+			gomega.Expect(blog).To(gomega.ContainSubstring("$settings['file_public_path'] = 'sites/default/files/blog';"))
+			gomega.Expect(blog).To(gomega.ContainSubstring("$config['s3fs.settings']['root_folder'] = 'site/blog';"))
+			gomega.Expect(blog).NotTo(gomega.ContainSubstring("'^example\\\\.com$'"))
+		})
+
+		ginkgo.It("renders nginx.conf", func() {
+			conf := renderNginx()["nginx.conf"]
+			gomega.Expect(conf).To(gomega.ContainSubstring("fastcgi_cache drupal;"))
+			gomega.Expect(conf).To(gomega.ContainSubstring("server_name example.com blog.example.com;"))
+			gomega.Expect(conf).To(gomega.ContainSubstring("return 301 https://example.com$request_uri;"))
+			gomega.Expect(conf).To(gomega.ContainSubstring("return 301 $forwarded_scheme://example.com$request_uri;"))
+			gomega.Expect(conf).To(gomega.ContainSubstring("listen 127.0.0.1:8080;"))
+			gomega.Expect(conf).To(gomega.ContainSubstring("stub_status;"))
+		})
+
+		ginkgo.It("renders default.vcl", func() {
+			vcl := renderVarnish()["default.vcl"]
+			gomega.Expect(vcl).To(gomega.ContainSubstring(`.host = "site-nginx";`))
+			gomega.Expect(vcl).To(gomega.ContainSubstring(`"192.168.0.0"/16;`))
+		})
+	})
+
+	ginkgo.When("alternative backends are used", func() {
+		ginkgo.BeforeEach(func() {
+			size := resource.MustParse("10Gi")
+			spec.Topology = drupalv1beta1.CombinedTopology
+			spec.CacheSpec = &drupalv1beta1.CacheSpec{Backend: drupalv1beta1.MemcacheCacheBackend}
+			spec.Drupal.MediaVolumeSpec = &drupalv1beta1.MediaVolumeSpec{
+				AzureBlobVolumeSource: &drupalv1beta1.AzureBlobVolumeSource{Account: "account", Container: "media"},
+			}
+			spec.Nginx.CacheSpec = &drupalv1beta1.PageCacheSpec{Size: &size}
+		})
+
+		ginkgo.It("renders settings.k8s.php", func() {
+			settings := renderDrupal()["settings.k8s.php"]
+			gomega.Expect(settings).To(gomega.ContainSubstring("$settings['memcache']['servers'] = array('site-cache:11211' => 'default');"))
+			gomega.Expect(settings).To(gomega.ContainSubstring("    'name' => 'account',"))
+		})
+
+		ginkgo.It("renders nginx.conf", func() {
+			conf := renderNginx()["nginx.conf"]
+			gomega.Expect(conf).To(gomega.ContainSubstring("127.0.0.1"))
+			gomega.Expect(conf).To(gomega.ContainSubstring("max_size=10240m"))
+		})
+	})
+
+	ginkgo.It("keeps template delimiters out of the static settings.php", func() {
+		gomega.Expect(strings.Contains(ConfigMapSettings, "[[")).To(gomega.BeFalse())
+		gomega.Expect(ConfigMapSettings).To(gomega.ContainSubstring("include __DIR__ . '/settings.k8s.php';"))
+	})
+
+	ginkgo.It("returns a RenderError if a template fails to parse", func() {
+		_, err := common.GenerateConfig("broken.conf", nil, "[[ if ]]")
+		gomega.Expect(err).To(gomega.HaveOccurred())
+		gomega.Expect(common.IsRenderError(err)).To(gomega.BeTrue())
+		gomega.Expect(err.Error()).To(gomega.ContainSubstring("unable to render broken.conf"))
+	})
+
+	ginkgo.It("returns a RenderError if a template fails to execute", func() {
+		_, err := common.GenerateConfig("broken.conf", syncNginx.Settings{}, "[[ .Missing ]]")
+		gomega.Expect(err).To(gomega.HaveOccurred())
+		gomega.Expect(common.IsRenderError(err)).To(gomega.BeTrue())
+	})
+})
@@ -0,0 +1,159 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package droplet
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
+	"github.com/sylus/drupal-operator/pkg/util/syncer"
+)
+
+const metricsNamespace = "drupal_operator"
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of the reconciles of a droplet, by result (success or error)",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"namespace", "droplet", "result"})
+
+	syncOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "sync_operations_total",
+		Help:      "Operations of the syncers of a droplet (created, updated, unchanged or failed)",
+	}, []string{"namespace", "droplet", "syncer", "operation"})
+
+	jobs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs",
+		Help:      "Jobs of a droplet by component and status (active, succeeded or failed)",
+	}, []string{"namespace", "droplet", "component", "status"})
+
+	dropletConditions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "droplet_condition",
+		Help:      "Conditions of a droplet, 1 for the current status of each condition",
+	}, []string{"namespace", "droplet", "condition", "status"})
+)
+
+var (
+	jobStatuses       = []string{"active", "succeeded", "failed"}
+	conditionTypes    = []drupalv1beta1.DropletConditionType{drupalv1beta1.Ready, drupalv1beta1.CertificateReady, drupalv1beta1.ConfigRenderFailed}
+	conditionStatuses = []corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown}
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDuration, syncOperations, jobs, dropletConditions)
+}
+
+func observeReconcile(key types.NamespacedName, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	reconcileDuration.WithLabelValues(key.Namespace, key.Name, result).Observe(duration.Seconds())
+}
+
+func observeSync(key types.NamespacedName, result syncer.SyncResult, err error) {
+	operation := string(result.Operation)
+	switch {
+	case err != nil:
+		operation = "failed"
+	case len(operation) == 0:
+		operation = "unchanged"
+	}
+	syncOperations.WithLabelValues(key.Namespace, key.Name, result.Name, operation).Inc()
+}
+
+// observeJobs counts the Jobs of a droplet by component and status
+func observeJobs(key types.NamespacedName, list *batchv1.JobList) {
+	counts := map[string]map[string]int{}
+	for _, component := range drupal.JobComponents {
+		counts[component.Name()] = map[string]int{}
+	}
+
+	for _, job := range list.Items {
+		byStatus, ok := counts[job.Labels["app.kubernetes.io/component"]]
+		if !ok {
+			continue
+		}
+		byStatus[jobStatus(&job)]++
+	}
+
+	for component, byStatus := range counts {
+		for _, status := range jobStatuses {
+			jobs.WithLabelValues(key.Namespace, key.Name, component, status).Set(float64(byStatus[status]))
+		}
+	}
+}
+
+func jobStatus(job *batchv1.Job) string {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return "succeeded"
+		case batchv1.JobFailed:
+			return "failed"
+		}
+	}
+	return "active"
+}
+
+// observeConditions sets the current status of each condition of a droplet
+// to 1 and the other statuses to 0
+func observeConditions(key types.NamespacedName, status drupalv1beta1.DropletStatus) {
+	for _, conditionType := range conditionTypes {
+		current := corev1.ConditionStatus("")
+		for _, c := range status.Conditions {
+			if c.Type == conditionType {
+				current = c.Status
+			}
+		}
+		for _, s := range conditionStatuses {
+			value := 0.0
+			if s == current {
+				value = 1
+			}
+			dropletConditions.WithLabelValues(key.Namespace, key.Name, string(conditionType), string(s)).Set(value)
+		}
+	}
+}
+
+// forgetDroplet deletes the gauges of a deleted droplet
+func forgetDroplet(key types.NamespacedName) {
+	for _, component := range drupal.JobComponents {
+		for _, status := range jobStatuses {
+			jobs.DeleteLabelValues(key.Namespace, key.Name, component.Name(), status)
+		}
+	}
+	for _, conditionType := range conditionTypes {
+		for _, s := range conditionStatuses {
+			dropletConditions.DeleteLabelValues(key.Namespace, key.Name, string(conditionType), string(s))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package droplet
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+)
+
+func TestJobStatus(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	job := &batchv1.Job{}
+	g.Expect(jobStatus(job)).To(gomega.Equal("active"))
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	g.Expect(jobStatus(job)).To(gomega.Equal("failed"))
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	g.Expect(jobStatus(job)).To(gomega.Equal("succeeded"))
+}
+
+func TestReadyCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var replicas int32 = 2
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Replicas = &replicas
+	deployment.Status.ReadyReplicas = 1
+
+	condition := readyCondition(deployment)
+	g.Expect(condition.Type).To(gomega.Equal(drupalv1beta1.Ready))
+	g.Expect(condition.Status).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(condition.Message).To(gomega.Equal("1 of 2 replicas ready"))
+
+	deployment.Status.ReadyReplicas = 2
+	g.Expect(readyCondition(deployment).Status).To(gomega.Equal(corev1.ConditionTrue))
+
+	// a droplet scaled to zero serves no requests
+	replicas = 0
+	deployment.Status.ReadyReplicas = 0
+	g.Expect(readyCondition(deployment).Status).To(gomega.Equal(corev1.ConditionFalse))
+}
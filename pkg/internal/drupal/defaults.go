@@ -15,11 +15,18 @@ limitations under the License.
 
 package drupal
 
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
 const (
 	defaultTag           = "0.0.1"
 	defaultImage         = "drupalwxt/site-canada"
 	codeSrcMountPath     = "/var/run/sylus.ca/code/src"
 	defaultCodeMountPath = "/var/www/html/modules/custom"
+	searchPort           = 8983
+
+	defaultRevisionHistoryLimit = 3
 )
 
 // SetDefaults sets Drupal field defaults
@@ -35,4 +42,15 @@ func (o *Drupal) SetDefaults() {
 	if o.Spec.Drupal.CodeVolumeSpec != nil && len(o.Spec.Drupal.CodeVolumeSpec.MountPath) == 0 {
 		o.Spec.Drupal.CodeVolumeSpec.MountPath = defaultCodeMountPath
 	}
+
+	if o.HasCodeArtifact() && len(o.Spec.Drupal.CodeVolumeSpec.GitDir.Artifact.PersistentVolumeClaim.AccessModes) == 0 {
+		o.Spec.Drupal.CodeVolumeSpec.GitDir.Artifact.PersistentVolumeClaim.AccessModes = []corev1.PersistentVolumeAccessMode{
+			corev1.ReadWriteMany,
+		}
+	}
+
+	if o.HasCodeArtifact() && o.Spec.Drupal.CodeVolumeSpec.GitDir.Artifact.RevisionHistoryLimit == nil {
+		limit := int32(defaultRevisionHistoryLimit)
+		o.Spec.Drupal.CodeVolumeSpec.GitDir.Artifact.RevisionHistoryLimit = &limit
+	}
 }
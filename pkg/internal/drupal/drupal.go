@@ -17,11 +17,17 @@ package drupal
 
 import (
 	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 
-	"github.com/cooleo/slugify"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
 	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/util/slugify"
 )
 
 // Drupal embeds drupalv1beta1.Droplet and adds utility functions
@@ -46,6 +52,18 @@ var (
 	DrupalCron = component{name: "cron", objNameFmt: "%s-drupal-cron"}
 	// DrupalDBUpgrade component
 	DrupalDBUpgrade = component{name: "upgrade", objNameFmt: "%s-upgrade"}
+	// DrupalHPA component
+	DrupalHPA = component{name: "web", objNameFmt: "%s"}
+	// DrupalPDB component
+	DrupalPDB = component{name: "web", objNameFmt: "%s"}
+	// DrupalCache component
+	DrupalCache = component{name: "cache", objNameFmt: "%s-cache"}
+	// DrupalSiteInstall component
+	DrupalSiteInstall = component{name: "install", objNameFmt: "%s-install"}
+	// DrupalSearch component
+	DrupalSearch = component{name: "search", objNameFmt: "%s-search"}
+	// DrupalSearchReindex component
+	DrupalSearchReindex = component{name: "search-reindex", objNameFmt: "%s-search-reindex"}
 	// DrupalService component
 	DrupalService = component{name: "web", objNameFmt: "%s"}
 	// DrupalIngress component
@@ -54,8 +72,25 @@ var (
 	DrupalCodePVC = component{name: "code", objNameFmt: "%s-code"}
 	// DrupalMediaPVC component
 	DrupalMediaPVC = component{name: "media", objNameFmt: "%s-media"}
+	// DrupalPrivateFilesPVC component
+	DrupalPrivateFilesPVC = component{name: "private-files", objNameFmt: "%s-private-files"}
+	// DrupalCodeArtifactPVC component
+	DrupalCodeArtifactPVC = component{name: "code-artifact", objNameFmt: "%s-code-artifact"}
+	// DrupalCodeBuild component
+	DrupalCodeBuild = component{name: "code-build", objNameFmt: "%s-code-build"}
+	// DrupalPodMonitor component
+	DrupalPodMonitor = component{name: "web", objNameFmt: "%s"}
 )
 
+// JobComponents are the components running as Jobs
+var JobComponents = []component{DrupalCron, DrupalDBUpgrade, DrupalSiteInstall, DrupalSearchReindex, DrupalCodeBuild}
+
+// Name returns the name of the component, as set in the
+// app.kubernetes.io/component label
+func (c component) Name() string {
+	return c.name
+}
+
 // New wraps a drupalv1beta1.Droplet into a Drupal object
 func New(obj *drupalv1beta1.Droplet) *Drupal {
 	return &Drupal{obj}
@@ -97,6 +132,14 @@ func (o *Drupal) ComponentLabels(component component) labels.Set {
 		l["drupal.sylus.ca/upgrade-for"] = o.ImageTagVersion()
 	}
 
+	if component == DrupalCodeBuild {
+		l["drupal.sylus.ca/code-revision"] = o.CodeRevision()
+	}
+
+	if component == DrupalSearchReindex {
+		l["drupal.sylus.ca/reindex-for"] = o.ReindexToken()
+	}
+
 	return l
 }
 
@@ -111,15 +154,175 @@ func (o *Drupal) ComponentName(component component) string {
 		name = fmt.Sprintf("%s-for-%s", name, o.ImageTagVersion())
 	}
 
+	if component == DrupalCodeBuild {
+		name = fmt.Sprintf("%s-for-%s", name, o.CodeRevision())
+	}
+
+	if component == DrupalSearchReindex {
+		name = fmt.Sprintf("%s-for-%s", name, o.ReindexToken())
+	}
+
 	return name
 }
 
+// SiteComponentName returns the object name for a component of a site of a
+// multisite
+func (o *Drupal) SiteComponentName(component component, site string) string {
+	return fmt.Sprintf("%s-%s%s", o.ObjectMeta.Name, site, strings.TrimPrefix(o.ComponentName(component), o.ObjectMeta.Name))
+}
+
+// SiteComponentLabels returns labels for a component of a site of a multisite
+func (o *Drupal) SiteComponentLabels(component component, site string) labels.Set {
+	l := o.ComponentLabels(component)
+	l["drupal.sylus.ca/site"] = site
+	return l
+}
+
+// SiteSettingsFile returns the key of the generated settings.k8s.php file of
+// a site in the drupal ConfigMap
+func SiteSettingsFile(site string) string {
+	return fmt.Sprintf("%s.settings.k8s.php", site)
+}
+
 // ImageTagVersion returns the version from the image tag in a format suitable
 // for kubernetes object names and labels
 func (o *Drupal) ImageTagVersion() string {
 	return slugify.Slugify(o.Spec.Drupal.Tag)
 }
 
+// ReindexToken returns the search reindex token in a format suitable for
+// kubernetes object names and labels
+func (o *Drupal) ReindexToken() string {
+	if o.Spec.SearchSpec == nil {
+		return ""
+	}
+	return slugify.Slugify(o.Spec.SearchSpec.ReindexToken)
+}
+
+var commitHashRegexp = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// CodeRevision returns the commit hash of the code shortened to its first 12
+// characters, for use in kubernetes object names, labels and directory names.
+// The git reference is returned as is if it is not a commit hash, which
+// ValidateCodeArtifact rejects before any object gets named after it.
+func (o *Drupal) CodeRevision() string {
+	if o.Spec.Drupal.CodeVolumeSpec == nil || o.Spec.Drupal.CodeVolumeSpec.GitDir == nil ||
+		len(o.Spec.Drupal.CodeVolumeSpec.GitDir.GitRef) == 0 {
+		return "latest"
+	}
+	ref := o.Spec.Drupal.CodeVolumeSpec.GitDir.GitRef
+	if commitHashRegexp.MatchString(ref) {
+		return ref[:12]
+	}
+	return ref
+}
+
+// Validate returns an error if the spec can't be reconciled
+func (o *Drupal) Validate() error {
+	if err := o.ValidateCodeArtifact(); err != nil {
+		return err
+	}
+	if err := o.ValidateTopology(); err != nil {
+		return err
+	}
+	if err := o.ValidatePrivateFiles(); err != nil {
+		return err
+	}
+	return o.ValidateSharedVolumes()
+}
+
+// ValidateTopology returns an error if nginx runs in pods of its own while
+// the code is cloned from git without an artifact, as every nginx pod would
+// clone the code again
+func (o *Drupal) ValidateTopology() error {
+	code := o.Spec.Drupal.CodeVolumeSpec
+	if o.IsCombined() || code == nil || code.GitDir == nil || o.HasCodeArtifact() {
+		return nil
+	}
+	return fmt.Errorf("spec.drupal.code.git.artifact must be set for the split topology, " +
+		"so that nginx pods mount the built code instead of cloning it")
+}
+
+// ValidateSharedVolumes returns an error if the media or code claims can't
+// be mounted by both the drupal and the nginx pods, which get scheduled on
+// different nodes unless nginx runs as a sidecar
+func (o *Drupal) ValidateSharedVolumes() error {
+	if o.IsCombined() {
+		return nil
+	}
+	if media := o.Spec.Drupal.MediaVolumeSpec; media != nil && media.PersistentVolumeClaim != nil &&
+		!hasAccessMode(media.PersistentVolumeClaim.AccessModes, corev1.ReadWriteMany) {
+		return fmt.Errorf("spec.drupal.media.persistentVolumeClaim must allow ReadWriteMany access, " +
+			"as the media files are mounted by the drupal and nginx pods")
+	}
+	if code := o.Spec.Drupal.CodeVolumeSpec; code != nil && code.GitDir == nil && code.PersistentVolumeClaim != nil &&
+		!hasAccessMode(code.PersistentVolumeClaim.AccessModes, corev1.ReadWriteMany) &&
+		!hasAccessMode(code.PersistentVolumeClaim.AccessModes, corev1.ReadOnlyMany) {
+		return fmt.Errorf("spec.drupal.code.persistentVolumeClaim must allow ReadWriteMany or ReadOnlyMany access, " +
+			"as the code is mounted by the drupal and nginx pods")
+	}
+	return nil
+}
+
+func hasAccessMode(modes []corev1.PersistentVolumeAccessMode, mode corev1.PersistentVolumeAccessMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePrivateFiles returns an error if both public and private files are
+// stored by the s3fs module in different buckets, as the module supports a
+// single bucket only
+func (o *Drupal) ValidatePrivateFiles() error {
+	if !o.PrivateFilesShareMediaBucket() {
+		return nil
+	}
+	media := o.Spec.Drupal.MediaVolumeSpec.S3VolumeSource
+	private := o.Spec.Drupal.PrivateFilesVolumeSpec.S3VolumeSource
+	if private.Bucket != media.Bucket || private.Region != media.Region || private.Endpoint != media.Endpoint {
+		return fmt.Errorf("spec.drupal.privateFiles.s3 must use the bucket, region and endpoint of spec.drupal.media.s3 "+
+			"when both use the s3fs module, got bucket %q instead of %q", private.Bucket, media.Bucket)
+	}
+	if len(private.Env) > 0 && !reflect.DeepEqual(private.Env, media.Env) {
+		return fmt.Errorf("spec.drupal.privateFiles.s3.env must be empty or equal to spec.drupal.media.s3.env " +
+			"when both use the s3fs module")
+	}
+	return nil
+}
+
+// PrivateFilesShareMediaBucket returns true if both public and private files
+// are stored by the s3fs module, which keeps them in the same bucket
+func (o *Drupal) PrivateFilesShareMediaBucket() bool {
+	media, private := o.Spec.Drupal.MediaVolumeSpec, o.Spec.Drupal.PrivateFilesVolumeSpec
+	return media != nil && media.S3VolumeSource != nil && media.S3VolumeSource.Module == "s3fs" &&
+		private != nil && private.S3VolumeSource != nil && private.S3VolumeSource.Module == "s3fs"
+}
+
+// ValidateCodeArtifact returns an error when the code is built into a shared
+// volume from a git reference which can move. A revision is only built once,
+// so a branch or tag would never get redeployed after being updated.
+func (o *Drupal) ValidateCodeArtifact() error {
+	if !o.HasCodeArtifact() {
+		return nil
+	}
+	ref := o.Spec.Drupal.CodeVolumeSpec.GitDir.GitRef
+	if !commitHashRegexp.MatchString(ref) {
+		return fmt.Errorf("spec.drupal.code.git.reference must be a full 40 character commit hash "+
+			"when spec.drupal.code.git.artifact is set, got %q", ref)
+	}
+	return nil
+}
+
+// HasCodeArtifact returns true if the code is built once per revision into a
+// shared volume instead of being cloned in every pod
+func (o *Drupal) HasCodeArtifact() bool {
+	return o.Spec.Drupal.CodeVolumeSpec != nil && o.Spec.Drupal.CodeVolumeSpec.GitDir != nil &&
+		o.Spec.Drupal.CodeVolumeSpec.GitDir.Artifact != nil
+}
+
 // PodLabels return labels to apply to web pods
 func (o *Drupal) PodLabels() labels.Set {
 	l := o.Labels()
@@ -127,6 +330,26 @@ func (o *Drupal) PodLabels() labels.Set {
 	return l
 }
 
+// CachePodLabels return labels to apply to managed cache pods. The version
+// label is left out, as it would change the immutable Deployment selector.
+func (o *Drupal) CachePodLabels() labels.Set {
+	return labels.Set{
+		"app.kubernetes.io/name":      "drupal",
+		"app.kubernetes.io/instance":  o.ObjectMeta.Name,
+		"app.kubernetes.io/component": DrupalCache.name,
+	}
+}
+
+// SearchPodLabels return labels to apply to managed Solr pods. The version
+// label is left out, as it would change the immutable StatefulSet selector.
+func (o *Drupal) SearchPodLabels() labels.Set {
+	return labels.Set{
+		"app.kubernetes.io/name":      "drupal",
+		"app.kubernetes.io/instance":  o.ObjectMeta.Name,
+		"app.kubernetes.io/component": DrupalSearch.name,
+	}
+}
+
 // JobPodLabels return labels to apply to cli job pods
 func (o *Drupal) JobPodLabels() labels.Set {
 	l := o.Labels()
@@ -142,3 +365,133 @@ func (o *Drupal) DataBaseBackend() (string, string) {
 
 	return "msql", "3306"
 }
+
+// HasMediaVolume returns true if media files are stored on a volume mounted
+// into the drupal runtime container rather than in object storage
+func (o *Drupal) HasMediaVolume() bool {
+	spec := o.Spec.Drupal.MediaVolumeSpec
+	return spec != nil && !spec.IsObjectStorage()
+}
+
+// MediaMountPath returns where the media volume is mounted in the drupal
+// runtime container
+func (o *Drupal) MediaMountPath() string {
+	if o.Spec.Drupal.MediaVolumeSpec == nil || len(o.Spec.Drupal.MediaVolumeSpec.MountPath) == 0 {
+		return path.Join(webrootMountPath, "sites/default/files")
+	}
+	return path.Clean(o.Spec.Drupal.MediaVolumeSpec.MountPath)
+}
+
+// MediaURLPath returns the URL path, with a trailing slash, under which the
+// media volume is served from the webroot. It is empty if the volume is
+// mounted outside of the webroot.
+func (o *Drupal) MediaURLPath() string {
+	rel, err := filepath.Rel(webrootMountPath, o.MediaMountPath())
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return "/" + rel + "/"
+}
+
+// IsCombined returns true if nginx runs as a sidecar in the drupal pods
+func (o *Drupal) IsCombined() bool {
+	return o.Spec.Topology == drupalv1beta1.CombinedTopology
+}
+
+// OverridesSettings returns true if the settings.php of the site gets
+// replaced by one including the generated settings
+func (o *Drupal) OverridesSettings() bool {
+	return o.Spec.Drupal.SettingsMode == drupalv1beta1.OverrideSettingsMode
+}
+
+// HasMonitoring returns true if the php-fpm exporter runs alongside Drupal
+func (o *Drupal) HasMonitoring() bool {
+	return o.Spec.MonitoringSpec != nil && o.Spec.MonitoringSpec.Enabled
+}
+
+// IsAutoscaled returns true if the drupal pods are scaled by a HorizontalPodAutoscaler
+func (o *Drupal) IsAutoscaled() bool {
+	return o.Spec.Drupal.AutoscalingSpec != nil
+}
+
+// HasPodDisruptionBudget returns true if the drupal pods are protected by a
+// PodDisruptionBudget
+func (o *Drupal) HasPodDisruptionBudget() bool {
+	if o.Spec.Drupal.PodDisruptionBudgetSpec != nil {
+		return true
+	}
+	if o.IsAutoscaled() && o.Spec.Drupal.AutoscalingSpec.MinReplicas != nil {
+		return *o.Spec.Drupal.AutoscalingSpec.MinReplicas > 1
+	}
+	return o.Spec.Drupal.Replicas != nil && *o.Spec.Drupal.Replicas > 1
+}
+
+// HasManagedCache returns true if the operator deploys the cache backend
+func (o *Drupal) HasManagedCache() bool {
+	return o.Spec.CacheSpec != nil && o.Spec.CacheSpec.External == nil
+}
+
+// CacheAddress returns the host and port of the cache server
+func (o *Drupal) CacheAddress() (string, int32) {
+	if o.Spec.CacheSpec.External != nil {
+		return o.Spec.CacheSpec.External.Host, o.Spec.CacheSpec.External.Port
+	}
+	return o.ComponentName(DrupalCache), o.Spec.CacheSpec.Backend.DefaultPort()
+}
+
+// CachePrefix returns the prefix of the cache keys of a site, which keeps the
+// sites of a multisite apart on a shared cache server. The default site is
+// passed as an empty site name.
+func (o *Drupal) CachePrefix(site string) string {
+	if len(site) == 0 {
+		return o.ObjectMeta.Name
+	}
+	return fmt.Sprintf("%s_%s", o.ObjectMeta.Name, site)
+}
+
+// HasManagedSearch returns true if the operator deploys the Solr server
+func (o *Drupal) HasManagedSearch() bool {
+	return o.Spec.SearchSpec != nil && o.Spec.SearchSpec.External == nil
+}
+
+// SearchAddress returns the scheme, host, port and path of the Solr server
+func (o *Drupal) SearchAddress() (string, string, int32, string) {
+	if o.Spec.SearchSpec.External != nil {
+		external := o.Spec.SearchSpec.External
+		return external.Scheme, external.Host, external.Port, external.Path
+	}
+	return "http", o.ComponentName(DrupalSearch), searchPort, "/"
+}
+
+// SearchCore returns the name of the Solr core of a site. Every site of a
+// multisite gets a core of its own, the default site is passed as an empty
+// site name.
+func (o *Drupal) SearchCore(site string) string {
+	if len(site) == 0 {
+		return o.Spec.SearchSpec.Core
+	}
+	return fmt.Sprintf("%s_%s", o.Spec.SearchSpec.Core, site)
+}
+
+// SearchCores returns the names of the Solr cores of all sites
+func (o *Drupal) SearchCores() []string {
+	out := []string{o.SearchCore("")}
+	for _, site := range o.Spec.Sites {
+		out = append(out, o.SearchCore(site.Name))
+	}
+	return out
+}
+
+// Scheme returns the scheme the site is served over, https if a TLS
+// certificate is configured
+func (o *Drupal) Scheme() string {
+	if len(o.Spec.TLSSecretRef) > 0 || (o.Spec.TLSSpec != nil && o.Spec.TLSSpec.IssuerRef != nil) {
+		return "https"
+	}
+	return "http"
+}
+
+// HomeURL returns the URL of the site's main domain
+func (o *Drupal) HomeURL() string {
+	return fmt.Sprintf("%s://%s", o.Scheme(), o.Spec.Domains[0])
+}
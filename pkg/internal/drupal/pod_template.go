@@ -17,18 +17,46 @@ package drupal
 
 import (
 	"fmt"
+	"path"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
 )
 
 //nolint
 const (
-	gitCloneImage   = "docker.io/library/buildpack-deps:stretch-scm"
-	drupalPort      = 9000
-	codeVolumeName  = "code"
-	mediaVolumeName = "media"
+	gitCloneImage          = "docker.io/library/buildpack-deps:stretch-scm"
+	drupalPort             = 9000
+	codeVolumeName         = "code"
+	mediaVolumeName        = "media"
+	privateFilesVolumeName = "private-files"
+	webrootVolumeName      = "webroot"
+	webrootMountPath       = "/var/www/html"
+	webrootCopyMountPath   = "/var/run/sylus.ca/webroot"
+	settingsFromMountPath  = "/var/run/sylus.ca/settings"
+	fpmStatusPath          = "/status"
+	fpmExporterPort        = 9253
 )
 
+// FPMStatusConfigKey is the key of the drupal ConfigMap holding the php-fpm
+// pool configuration enabling the status page
+const FPMStatusConfigKey = "php-fpm-status.conf"
+
+// FPMStatusConfig enables the status page of the php-fpm www pool, for the
+// exporter to scrape
+const FPMStatusConfig = `[www]
+pm.status_path = ` + fpmStatusPath + `
+`
+
+// PrivateFilesPath is where private files are mounted in the drupal runtime
+// container, unless the private files volume spec sets a MountPath
+const PrivateFilesPath = "/var/www/files_private"
+
+// PrivateFilesEnvPrefix prefixes the credentials of the private files bucket
+const PrivateFilesEnvPrefix = "PRIVATE_FILES_"
+
 const gitCloneScript = `#!/bin/bash
 set -e
 set -o pipefail
@@ -57,6 +85,59 @@ cd "$SRC_DIR"
 git checkout -B "$GIT_CLONE_REF" "$GIT_CLONE_REF"
 `
 
+const codeBuildScript = `#!/bin/bash
+set -e
+set -o pipefail
+
+export HOME="$(mktemp -d)"
+export GIT_SSH_COMMAND="ssh -o UserKnownHostsFile=$HOME/.ssh/known_hosts -o StrictHostKeyChecking=no"
+
+test -d "$HOME/.ssh" || mkdir "$HOME/.ssh"
+
+if [ ! -z "$SSH_RSA_PRIVATE_KEY" ] ; then
+    echo "$SSH_RSA_PRIVATE_KEY" > "$HOME/.ssh/id_rsa"
+    chmod 0400 "$HOME/.ssh/id_rsa"
+    export GIT_SSH_COMMAND="$GIT_SSH_COMMAND -o IdentityFile=$HOME/.ssh/id_rsa"
+fi
+
+if [ -z "$GIT_CLONE_URL" ] ; then
+    echo "No \$GIT_CLONE_URL specified" >&2
+    exit 1
+fi
+
+# keeps the $REVISION_HISTORY_LIMIT most recently built or deployed revisions
+prune_revisions() {
+    touch "$SRC_DIR/$CODE_REVISION"
+    ls -1dt "$SRC_DIR"/*/ | tail -n +$((${REVISION_HISTORY_LIMIT:-3} + 1)) | xargs -r rm -rf
+}
+
+if [ -d "$SRC_DIR/$CODE_REVISION" ] ; then
+    echo "Code revision $CODE_REVISION is already built"
+    prune_revisions
+    exit 0
+fi
+
+BUILD_DIR="$SRC_DIR/.$CODE_REVISION.tmp"
+rm -rf "$BUILD_DIR"
+
+set -x
+git clone "$GIT_CLONE_URL" "$BUILD_DIR"
+cd "$BUILD_DIR"
+git checkout -B "$GIT_CLONE_REF" "$GIT_CLONE_REF"
+cd "$SRC_DIR"
+
+# the rename is atomic, so pods never see a partially built revision
+mv "$BUILD_DIR" "$SRC_DIR/$CODE_REVISION"
+prune_revisions
+`
+
+const waitForCodeScript = `#!/bin/sh
+until [ -d "$SRC_DIR/$CODE_REVISION" ] ; do
+    echo "Waiting for code revision $CODE_REVISION to be built"
+    sleep 5
+done
+`
+
 var (
 	wwwDataUserID int64 = 33
 )
@@ -82,43 +163,108 @@ func (droplet *Drupal) env() []corev1.EnvVar {
 	out := append([]corev1.EnvVar{
 		{
 			Name:  "DRUPAL_HOME",
-			Value: fmt.Sprintf("http://%s", droplet.Spec.Domains[0]),
+			Value: droplet.HomeURL(),
 		},
 		{
 			Name:  "DRUPAL_SITEURL",
-			Value: fmt.Sprintf("http://%s/droplet", droplet.Spec.Domains[0]),
+			Value: fmt.Sprintf("%s/droplet", droplet.HomeURL()),
 		},
 	}, droplet.Spec.Drupal.Env...)
 
-	if droplet.Spec.Drupal.MediaVolumeSpec != nil {
-		if droplet.Spec.Drupal.MediaVolumeSpec.S3VolumeSource != nil {
-			for _, env := range droplet.Spec.Drupal.MediaVolumeSpec.S3VolumeSource.Env {
-				if name, ok := s3EnvVars[env.Name]; ok {
-					_env := env.DeepCopy()
-					_env.Name = name
-					out = append(out, *_env)
-				}
+	out = append(out, objectStorageEnv("MEDIA", "", droplet.Spec.Drupal.MediaVolumeSpec)...)
+
+	if droplet.Spec.CacheSpec != nil && droplet.Spec.CacheSpec.External != nil && len(droplet.Spec.CacheSpec.External.PasswordSecretRef) > 0 {
+		out = append(out, corev1.EnvVar{
+			Name: "CACHE_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: string(droplet.Spec.CacheSpec.External.PasswordSecretRef),
+					},
+					Key: "CACHE_PASSWORD",
+				},
+			},
+		})
+	}
+
+	// the credentials of the private files bucket are prefixed as they may
+	// differ from the media bucket's
+	out = append(out, objectStorageEnv("PRIVATE_FILES", PrivateFilesEnvPrefix, droplet.Spec.Drupal.PrivateFilesVolumeSpec)...)
+
+	return out
+}
+
+// objectStorageEnv returns the environment variables for accessing the bucket
+// of the given volume spec. The bucket variables are prefixed with prefix and
+// the credentials with credentialsPrefix.
+func objectStorageEnv(prefix, credentialsPrefix string, spec *drupalv1beta1.MediaVolumeSpec) (out []corev1.EnvVar) {
+	if spec == nil {
+		return nil
+	}
+
+	// only one backend is used, with the same precedence as the settings
+	switch {
+	case spec.S3VolumeSource != nil:
+		out = append(out, corev1.EnvVar{
+			Name:  prefix + "_BUCKET",
+			Value: fmt.Sprintf("s3://%s", spec.S3VolumeSource.Bucket),
+		})
+		out = append(out, corev1.EnvVar{
+			Name:  prefix + "_BUCKET_PREFIX",
+			Value: spec.S3VolumeSource.PathPrefix,
+		})
+		for _, env := range spec.S3VolumeSource.Env {
+			if name, ok := s3EnvVars[env.Name]; ok {
+				_env := env.DeepCopy()
+				_env.Name = credentialsPrefix + name
+				out = append(out, *_env)
 			}
 		}
-
-		if droplet.Spec.Drupal.MediaVolumeSpec.GCSVolumeSource != nil {
-			out = append(out, corev1.EnvVar{
-				Name:  "MEDIA_BUCKET",
-				Value: fmt.Sprintf("gs://%s", droplet.Spec.Drupal.MediaVolumeSpec.GCSVolumeSource.Bucket),
-			})
+	case spec.GCSVolumeSource != nil:
+		out = append(out, corev1.EnvVar{
+			Name:  prefix + "_BUCKET",
+			Value: fmt.Sprintf("gs://%s", spec.GCSVolumeSource.Bucket),
+		})
+		out = append(out, corev1.EnvVar{
+			Name:  prefix + "_BUCKET_PREFIX",
+			Value: spec.GCSVolumeSource.PathPrefix,
+		})
+		for _, env := range spec.GCSVolumeSource.Env {
+			if name, ok := gcsEnvVars[env.Name]; ok {
+				_env := env.DeepCopy()
+				_env.Name = credentialsPrefix + name
+				out = append(out, *_env)
+			}
+		}
+	case spec.AzureBlobVolumeSource != nil:
+		azure := spec.AzureBlobVolumeSource
+		out = append(out, corev1.EnvVar{
+			Name:  prefix + "_BUCKET",
+			Value: fmt.Sprintf("https://%s.blob.core.windows.net/%s", azure.Account, azure.Container),
+		})
+		out = append(out, corev1.EnvVar{
+			Name:  prefix + "_BUCKET_PREFIX",
+			Value: azure.PathPrefix,
+		})
+		out = append(out, corev1.EnvVar{
+			Name:  credentialsPrefix + "AZURE_STORAGE_ACCOUNT",
+			Value: azure.Account,
+		})
+		if len(azure.CredentialsSecretRef) > 0 {
 			out = append(out, corev1.EnvVar{
-				Name:  "MEDIA_BUCKET_PREFIX",
-				Value: droplet.Spec.Drupal.MediaVolumeSpec.GCSVolumeSource.PathPrefix,
+				Name: credentialsPrefix + "AZURE_STORAGE_KEY",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: string(azure.CredentialsSecretRef),
+						},
+						Key: "AZURE_STORAGE_KEY",
+					},
+				},
 			})
-			for _, env := range droplet.Spec.Drupal.MediaVolumeSpec.GCSVolumeSource.Env {
-				if name, ok := gcsEnvVars[env.Name]; ok {
-					_env := env.DeepCopy()
-					_env.Name = name
-					out = append(out, *_env)
-				}
-			}
 		}
 	}
+
 	return out
 }
 
@@ -161,6 +307,19 @@ func (droplet *Drupal) gitCloneEnv() []corev1.EnvVar {
 		})
 	}
 
+	if droplet.HasCodeArtifact() {
+		out = append(out, corev1.EnvVar{
+			Name:  "CODE_REVISION",
+			Value: droplet.CodeRevision(),
+		})
+		if limit := droplet.Spec.Drupal.CodeVolumeSpec.GitDir.Artifact.RevisionHistoryLimit; limit != nil {
+			out = append(out, corev1.EnvVar{
+				Name:  "REVISION_HISTORY_LIMIT",
+				Value: fmt.Sprintf("%d", *limit),
+			})
+		}
+	}
+
 	out = append(out, droplet.Spec.Drupal.CodeVolumeSpec.GitDir.Env...)
 
 	return out
@@ -169,14 +328,132 @@ func (droplet *Drupal) gitCloneEnv() []corev1.EnvVar {
 func (droplet *Drupal) volumeMounts() (out []corev1.VolumeMount) {
 	out = droplet.Spec.Drupal.VolumeMounts
 
+	out = append(out, droplet.settingsVolumeMounts("default", "settings.k8s.php")...)
+
+	if len(droplet.Spec.Sites) > 0 {
+		out = append(out, corev1.VolumeMount{
+			Name:      "cm-drupal",
+			MountPath: "/var/www/html/sites/sites.php",
+			SubPath:   "sites.php",
+		})
+	}
+	for _, site := range droplet.Spec.Sites {
+		out = append(out, droplet.settingsVolumeMounts(site.Name, SiteSettingsFile(site.Name))...)
+	}
+
+	out = append(out, droplet.codeVolumeMounts()...)
+
+	if droplet.HasMediaVolume() {
+		out = append(out, corev1.VolumeMount{
+			Name:      mediaVolumeName,
+			MountPath: droplet.Spec.Drupal.MediaVolumeSpec.MountPath,
+			ReadOnly:  droplet.Spec.Drupal.MediaVolumeSpec.ReadOnly,
+			SubPath:   droplet.Spec.Drupal.MediaVolumeSpec.SubPath,
+		})
+	}
+
+	if droplet.hasPrivateFilesVolume() {
+		out = append(out, corev1.VolumeMount{
+			Name:      privateFilesVolumeName,
+			MountPath: droplet.PrivateFilesMountPath(),
+			ReadOnly:  droplet.Spec.Drupal.PrivateFilesVolumeSpec.ReadOnly,
+			SubPath:   droplet.Spec.Drupal.PrivateFilesVolumeSpec.SubPath,
+		})
+	}
+
+	for i := range droplet.Spec.Drupal.SettingsFrom {
+		out = append(out, corev1.VolumeMount{
+			Name:      settingsFromVolumeName(i),
+			MountPath: path.Dir(SettingsFromPath(i)),
+			ReadOnly:  true,
+		})
+	}
+
+	if droplet.HasMonitoring() {
+		// the zz- prefix makes php-fpm load it after the image's www.conf
+		out = append(out, corev1.VolumeMount{
+			Name:      "cm-drupal",
+			MountPath: "/usr/local/etc/php-fpm.d/zz-status.conf",
+			SubPath:   FPMStatusConfigKey,
+		})
+	}
+	return out
+}
+
+func settingsFromVolumeName(i int) string {
+	return fmt.Sprintf("settings-from-%d", i)
+}
+
+// SettingsFromPath returns where the i-th settings.php snippet of
+// spec.drupal.settingsFrom is mounted
+func SettingsFromPath(i int) string {
+	return fmt.Sprintf("%s/%d/settings.php", settingsFromMountPath, i)
+}
+
+// settingsFromVolumes returns the volumes holding the settings.php snippets,
+// each projecting the selected key to settings.php
+func (droplet *Drupal) settingsFromVolumes() (out []corev1.Volume) {
+	for i, source := range droplet.Spec.Drupal.SettingsFrom {
+		volume := corev1.Volume{Name: settingsFromVolumeName(i)}
+		switch {
+		case source.ConfigMapKeyRef != nil:
+			volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: source.ConfigMapKeyRef.LocalObjectReference,
+				Items:                []corev1.KeyToPath{{Key: source.ConfigMapKeyRef.Key, Path: "settings.php"}},
+				Optional:             source.ConfigMapKeyRef.Optional,
+			}
+		case source.SecretKeyRef != nil:
+			volume.Secret = &corev1.SecretVolumeSource{
+				SecretName: source.SecretKeyRef.Name,
+				Items:      []corev1.KeyToPath{{Key: source.SecretKeyRef.Key, Path: "settings.php"}},
+				Optional:   source.SecretKeyRef.Optional,
+			}
+		default:
+			volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		}
+		out = append(out, volume)
+	}
+	return out
+}
+
+// settingsVolumeMounts returns the mounts of the generated settings into the
+// directory of a site. In override mode, the settings.php of the site gets
+// replaced by one including the generated settings.
+func (droplet *Drupal) settingsVolumeMounts(dir, key string) (out []corev1.VolumeMount) {
 	out = append(out, corev1.VolumeMount{
 		Name:      "cm-drupal",
-		MountPath: "/var/www/html/sites/default/settings.php",
-		ReadOnly:  false,
-		SubPath:   "d8.settings.php",
+		MountPath: fmt.Sprintf("/var/www/html/sites/%s/settings.k8s.php", dir),
+		SubPath:   key,
 	})
 
-	if droplet.Spec.Drupal.CodeVolumeSpec != nil {
+	if droplet.OverridesSettings() {
+		out = append(out, corev1.VolumeMount{
+			Name:      "cm-drupal",
+			MountPath: fmt.Sprintf("/var/www/html/sites/%s/settings.php", dir),
+			SubPath:   "d8.settings.php",
+		})
+	}
+	return out
+}
+
+// codeVolumeMounts returns the mounts of the code volume into containers
+// running drupal code
+func (droplet *Drupal) codeVolumeMounts() (out []corev1.VolumeMount) {
+	if droplet.HasCodeArtifact() {
+		// mount only the current revision out of the shared code volume
+		out = append(out, corev1.VolumeMount{
+			Name:      codeVolumeName,
+			MountPath: codeSrcMountPath,
+			ReadOnly:  true,
+			SubPath:   droplet.CodeRevision(),
+		})
+		out = append(out, corev1.VolumeMount{
+			Name:      codeVolumeName,
+			MountPath: droplet.Spec.Drupal.CodeVolumeSpec.MountPath,
+			ReadOnly:  true,
+			SubPath:   path.Join(droplet.CodeRevision(), droplet.Spec.Drupal.CodeVolumeSpec.ContentSubPath),
+		})
+	} else if droplet.Spec.Drupal.CodeVolumeSpec != nil {
 		out = append(out, corev1.VolumeMount{
 			Name:      codeVolumeName,
 			MountPath: codeSrcMountPath,
@@ -202,6 +479,15 @@ func (droplet *Drupal) codeVolume() corev1.Volume {
 
 	if droplet.Spec.Drupal.CodeVolumeSpec != nil {
 		switch {
+		case droplet.HasCodeArtifact():
+			drupalCodeVolume = corev1.Volume{
+				Name: codeVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: droplet.ComponentName(DrupalCodeArtifactPVC),
+					},
+				},
+			}
 		case droplet.Spec.Drupal.CodeVolumeSpec.GitDir != nil:
 			if droplet.Spec.Drupal.CodeVolumeSpec.GitDir.EmptyDir != nil {
 				drupalCodeVolume.EmptyDir = droplet.Spec.Drupal.CodeVolumeSpec.GitDir.EmptyDir
@@ -264,6 +550,55 @@ func (droplet *Drupal) mediaVolume() corev1.Volume {
 	return drupalMediaVolume
 }
 
+// PrivateFilesMountPath returns where private files are mounted in the drupal
+// runtime container
+func (droplet *Drupal) PrivateFilesMountPath() string {
+	spec := droplet.Spec.Drupal.PrivateFilesVolumeSpec
+	if spec == nil || len(spec.MountPath) == 0 {
+		return PrivateFilesPath
+	}
+	return spec.MountPath
+}
+
+// hasPrivateFilesVolume returns true if private files are stored on a volume
+// rather than in object storage
+func (droplet *Drupal) hasPrivateFilesVolume() bool {
+	spec := droplet.Spec.Drupal.PrivateFilesVolumeSpec
+	return spec != nil && !spec.IsObjectStorage()
+}
+
+func (droplet *Drupal) privateFilesVolume() corev1.Volume {
+	privateFilesVolume := corev1.Volume{
+		Name: privateFilesVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+
+	switch {
+	case droplet.Spec.Drupal.PrivateFilesVolumeSpec.PersistentVolumeClaim != nil:
+		privateFilesVolume = corev1.Volume{
+			Name: privateFilesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: droplet.ComponentName(DrupalPrivateFilesPVC),
+				},
+			},
+		}
+	case droplet.Spec.Drupal.PrivateFilesVolumeSpec.HostPath != nil:
+		privateFilesVolume = corev1.Volume{
+			Name: privateFilesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: droplet.Spec.Drupal.PrivateFilesVolumeSpec.HostPath,
+			},
+		}
+	case droplet.Spec.Drupal.PrivateFilesVolumeSpec.EmptyDir != nil:
+		privateFilesVolume.EmptyDir = droplet.Spec.Drupal.PrivateFilesVolumeSpec.EmptyDir
+	}
+
+	return privateFilesVolume
+}
+
 func (droplet *Drupal) configMap() corev1.Volume {
 	configMap := corev1.Volume{
 		Name: "cm-drupal",
@@ -280,7 +615,12 @@ func (droplet *Drupal) configMap() corev1.Volume {
 }
 
 func (droplet *Drupal) volumes() []corev1.Volume {
-	return append(droplet.Spec.Drupal.Volumes, droplet.configMap(), droplet.codeVolume(), droplet.mediaVolume())
+	out := append(droplet.Spec.Drupal.Volumes, droplet.configMap(), droplet.codeVolume(), droplet.mediaVolume())
+	if droplet.hasPrivateFilesVolume() {
+		out = append(out, droplet.privateFilesVolume())
+	}
+	out = append(out, droplet.settingsFromVolumes()...)
+	return out
 }
 
 func (droplet *Drupal) gitCloneContainer() corev1.Container {
@@ -302,6 +642,202 @@ func (droplet *Drupal) gitCloneContainer() corev1.Container {
 	}
 }
 
+func (droplet *Drupal) waitForCodeContainer() corev1.Container {
+	return corev1.Container{
+		Name:  "wait-for-code",
+		Args:  []string{"/bin/sh", "-c", waitForCodeScript},
+		Image: gitCloneImage,
+		Env: []corev1.EnvVar{
+			{
+				Name:  "SRC_DIR",
+				Value: codeSrcMountPath,
+			},
+			{
+				Name:  "CODE_REVISION",
+				Value: droplet.CodeRevision(),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      codeVolumeName,
+				MountPath: codeSrcMountPath,
+				ReadOnly:  true,
+			},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser: &wwwDataUserID,
+		},
+	}
+}
+
+func (droplet *Drupal) initContainers() []corev1.Container {
+	if droplet.HasCodeArtifact() {
+		return []corev1.Container{
+			droplet.waitForCodeContainer(),
+		}
+	}
+
+	if droplet.Spec.Drupal.CodeVolumeSpec != nil && droplet.Spec.Drupal.CodeVolumeSpec.GitDir != nil {
+		return []corev1.Container{
+			droplet.gitCloneContainer(),
+		}
+	}
+
+	return nil
+}
+
+func (droplet *Drupal) copyWebrootContainer() corev1.Container {
+	return corev1.Container{
+		Name:  "copy-webroot",
+		Image: droplet.image(),
+		Args: []string{
+			"/bin/sh", "-c",
+			fmt.Sprintf("cp -a %s/. %s/", webrootMountPath, webrootCopyMountPath),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      webrootVolumeName,
+				MountPath: webrootCopyMountPath,
+			},
+		},
+	}
+}
+
+// StaticAssetsInitContainers returns the init containers which populate the
+// volumes returned by StaticAssetsVolumes. The webroot is copied out of the
+// drupal runtime image. Code from git is never cloned, the built artifact
+// gets mounted read-only once available.
+func (droplet *Drupal) StaticAssetsInitContainers() []corev1.Container {
+	out := []corev1.Container{droplet.copyWebrootContainer()}
+	if droplet.HasCodeArtifact() {
+		out = append(out, droplet.waitForCodeContainer())
+	}
+	return out
+}
+
+// StaticAssetsVolumes returns the volumes holding the drupal webroot and code
+// for pods serving static assets, such as nginx
+func (droplet *Drupal) StaticAssetsVolumes() []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: webrootVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+		droplet.codeVolume(),
+	}
+}
+
+// StaticAssetsVolumeMounts returns the read-only mounts of the volumes
+// returned by StaticAssetsVolumes, laid out as in the drupal runtime container
+func (droplet *Drupal) StaticAssetsVolumeMounts() []corev1.VolumeMount {
+	out := []corev1.VolumeMount{
+		{
+			Name:      webrootVolumeName,
+			MountPath: webrootMountPath,
+			ReadOnly:  true,
+		},
+	}
+
+	for _, mount := range droplet.codeVolumeMounts() {
+		mount.ReadOnly = true
+		out = append(out, mount)
+	}
+
+	return out
+}
+
+// php-fpm does not speak http, so it is probed on its fastcgi port
+func (droplet *Drupal) livenessProbe() *corev1.Probe {
+	if droplet.Spec.Drupal.PodSpec != nil && droplet.Spec.Drupal.PodSpec.LivenessProbe != nil {
+		return droplet.Spec.Drupal.PodSpec.LivenessProbe
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(drupalPort),
+			},
+		},
+		InitialDelaySeconds: 30,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      5,
+		SuccessThreshold:    1,
+		FailureThreshold:    6,
+	}
+}
+
+func (droplet *Drupal) readinessProbe() *corev1.Probe {
+	if droplet.Spec.Drupal.PodSpec != nil && droplet.Spec.Drupal.PodSpec.ReadinessProbe != nil {
+		return droplet.Spec.Drupal.PodSpec.ReadinessProbe
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(drupalPort),
+			},
+		},
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       5,
+		TimeoutSeconds:      5,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+	}
+}
+
+func (droplet *Drupal) resources() corev1.ResourceRequirements {
+	if droplet.Spec.Drupal.PodSpec == nil {
+		return corev1.ResourceRequirements{}
+	}
+	return droplet.Spec.Drupal.PodSpec.Resources
+}
+
+// setScheduling sets the scheduling overrides of spec.drupal.pod
+func (droplet *Drupal) setScheduling(out *corev1.PodSpec) {
+	// fields are always assigned, so that removed constraints get removed
+	spec := droplet.Spec.Drupal.PodSpec
+	if spec == nil {
+		spec = &drupalv1beta1.PodSpec{}
+	}
+	out.NodeSelector = spec.NodeSelector
+	out.Affinity = spec.Affinity
+	out.Tolerations = spec.Tolerations
+	out.PriorityClassName = spec.PriorityClassName
+	out.TopologySpreadConstraints = spec.TopologySpreadConstraints
+}
+
+func (droplet *Drupal) startupProbe() *corev1.Probe {
+	if droplet.Spec.Drupal.PodSpec == nil {
+		return nil
+	}
+	return droplet.Spec.Drupal.PodSpec.StartupProbe
+}
+
+// fpmExporterContainer returns the sidecar exporting the php-fpm status as
+// Prometheus metrics
+func (droplet *Drupal) fpmExporterContainer() corev1.Container {
+	return corev1.Container{
+		Name:  "php-fpm-exporter",
+		Image: droplet.Spec.MonitoringSpec.PHPFPMExporterImage,
+		Env: []corev1.EnvVar{
+			{
+				Name:  "PHP_FPM_SCRAPE_URI",
+				Value: fmt.Sprintf("tcp://127.0.0.1:%d%s", drupalPort, fpmStatusPath),
+			},
+			{
+				Name:  "PHP_FPM_WEB_LISTEN_ADDRESS",
+				Value: fmt.Sprintf(":%d", fpmExporterPort),
+			},
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          "fpm-metrics",
+				ContainerPort: int32(fpmExporterPort),
+			},
+		},
+	}
+}
+
 // PodTemplateSpec generates a pod template spec suitable for use with Drupal
 func (droplet *Drupal) PodTemplateSpec() (out corev1.PodTemplateSpec) {
 	out = corev1.PodTemplateSpec{}
@@ -312,11 +848,7 @@ func (droplet *Drupal) PodTemplateSpec() (out corev1.PodTemplateSpec) {
 		out.Spec.ServiceAccountName = droplet.Spec.ServiceAccountName
 	}
 
-	if droplet.Spec.Drupal.CodeVolumeSpec != nil && droplet.Spec.Drupal.CodeVolumeSpec.GitDir != nil {
-		out.Spec.InitContainers = []corev1.Container{
-			droplet.gitCloneContainer(),
-		}
-	}
+	out.Spec.InitContainers = droplet.initContainers()
 
 	out.Spec.Containers = []corev1.Container{
 		{
@@ -331,11 +863,21 @@ func (droplet *Drupal) PodTemplateSpec() (out corev1.PodTemplateSpec) {
 					ContainerPort: int32(drupalPort),
 				},
 			},
+			Resources:      droplet.resources(),
+			LivenessProbe:  droplet.livenessProbe(),
+			ReadinessProbe: droplet.readinessProbe(),
+			StartupProbe:   droplet.startupProbe(),
 		},
 	}
 
+	if droplet.HasMonitoring() {
+		out.Spec.Containers = append(out.Spec.Containers, droplet.fpmExporterContainer())
+	}
+
 	out.Spec.Volumes = droplet.volumes()
 
+	droplet.setScheduling(&out.Spec)
+
 	out.Spec.SecurityContext = &corev1.PodSecurityContext{
 		FSGroup: &wwwDataUserID,
 	}
@@ -355,11 +897,7 @@ func (droplet *Drupal) JobPodTemplateSpec(cmd ...string) (out corev1.PodTemplate
 
 	out.Spec.RestartPolicy = corev1.RestartPolicyNever
 
-	if droplet.Spec.Drupal.CodeVolumeSpec != nil && droplet.Spec.Drupal.CodeVolumeSpec.GitDir != nil {
-		out.Spec.InitContainers = []corev1.Container{
-			droplet.gitCloneContainer(),
-		}
-	}
+	out.Spec.InitContainers = droplet.initContainers()
 
 	out.Spec.Containers = []corev1.Container{
 		{
@@ -369,11 +907,55 @@ func (droplet *Drupal) JobPodTemplateSpec(cmd ...string) (out corev1.PodTemplate
 			VolumeMounts: droplet.volumeMounts(),
 			Env:          droplet.env(),
 			EnvFrom:      droplet.envFrom(),
+			Resources:    droplet.resources(),
 		},
 	}
 
 	out.Spec.Volumes = droplet.volumes()
 
+	droplet.setScheduling(&out.Spec)
+
+	out.Spec.SecurityContext = &corev1.PodSecurityContext{
+		FSGroup: &wwwDataUserID,
+	}
+
+	return out
+}
+
+// CodeBuildPodTemplateSpec generates a pod template spec suitable for building
+// the current code revision into the shared code volume
+func (droplet *Drupal) CodeBuildPodTemplateSpec() (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+	out.ObjectMeta.Labels = droplet.JobPodLabels()
+
+	out.Spec.ImagePullSecrets = droplet.Spec.Drupal.ImagePullSecrets
+	if len(droplet.Spec.ServiceAccountName) > 0 {
+		out.Spec.ServiceAccountName = droplet.Spec.ServiceAccountName
+	}
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:    "code-build",
+			Args:    []string{"/bin/bash", "-c", codeBuildScript},
+			Image:   gitCloneImage,
+			Env:     droplet.gitCloneEnv(),
+			EnvFrom: droplet.Spec.Drupal.CodeVolumeSpec.GitDir.EnvFrom,
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      codeVolumeName,
+					MountPath: codeSrcMountPath,
+				},
+			},
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser: &wwwDataUserID,
+			},
+		},
+	}
+
+	out.Spec.Volumes = []corev1.Volume{droplet.codeVolume()}
+
 	out.Spec.SecurityContext = &corev1.PodSecurityContext{
 		FSGroup: &wwwDataUserID,
 	}
@@ -18,10 +18,10 @@ package nginx
 import (
 	"fmt"
 
-	"github.com/cooleo/slugify"
 	"k8s.io/apimachinery/pkg/labels"
 
 	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/util/slugify"
 )
 
 // Nginx embeds drupalv1beta1.Droplet and adds utility functions
@@ -46,14 +46,24 @@ var (
 	NginxCron = component{name: "cron", objNameFmt: "%s-nginx-cron"}
 	// NginxDBUpgrade component
 	NginxDBUpgrade = component{name: "upgrade", objNameFmt: "%s-upgrade"}
+	// NginxHPA component
+	NginxHPA = component{name: "web", objNameFmt: "%s-nginx"}
+	// NginxPDB component
+	NginxPDB = component{name: "web", objNameFmt: "%s-nginx"}
 	// NginxService component
 	NginxService = component{name: "web", objNameFmt: "%s"}
 	// NginxIngress component
 	NginxIngress = component{name: "web", objNameFmt: "%s"}
+	// NginxHTTPRoute component
+	NginxHTTPRoute = component{name: "web", objNameFmt: "%s"}
+	// NginxCertificate component
+	NginxCertificate = component{name: "web", objNameFmt: "%s-tls"}
 	// NginxCodePVC component
 	NginxCodePVC = component{name: "code", objNameFmt: "%s-code"}
 	// NginxMediaPVC component
 	NginxMediaPVC = component{name: "media", objNameFmt: "%s-media"}
+	// NginxVarnish component
+	NginxVarnish = component{name: "varnish", objNameFmt: "%s-varnish"}
 )
 
 // New wraps a drupalv1beta1.Droplet into a Nginx object
@@ -133,3 +143,113 @@ func (o *Nginx) JobPodLabels() labels.Set {
 	l["app.kubernetes.io/component"] = "nginx-cli"
 	return l
 }
+
+// HasMediaVolume returns true if media files are stored on a volume which can
+// be shared with the nginx pods, so nginx serves them directly
+func (o *Nginx) HasMediaVolume() bool {
+	spec := o.Spec.Drupal.MediaVolumeSpec
+	if spec == nil || spec.IsObjectStorage() {
+		return false
+	}
+	// an emptyDir can only be shared with nginx running in the drupal pods
+	return spec.PersistentVolumeClaim != nil || spec.HostPath != nil || o.IsCombined()
+}
+
+// MediaURLPath returns the URL path under which nginx serves the media volume,
+// following where it is mounted in the drupal runtime container
+func (o *Nginx) MediaURLPath() string {
+	return o.drupal().MediaURLPath()
+}
+
+// IsCombined returns true if nginx runs as a sidecar in the drupal pods
+func (o *Nginx) IsCombined() bool {
+	return o.Spec.Topology == drupalv1beta1.CombinedTopology
+}
+
+// IsAutoscaled returns true if the nginx pods are scaled by a HorizontalPodAutoscaler
+func (o *Nginx) IsAutoscaled() bool {
+	return o.Spec.Nginx.AutoscalingSpec != nil
+}
+
+// HasPodDisruptionBudget returns true if the nginx pods are protected by a
+// PodDisruptionBudget
+func (o *Nginx) HasPodDisruptionBudget() bool {
+	if o.Spec.Nginx.PodDisruptionBudgetSpec != nil {
+		return true
+	}
+	if o.IsAutoscaled() && o.Spec.Nginx.AutoscalingSpec.MinReplicas != nil {
+		return *o.Spec.Nginx.AutoscalingSpec.MinReplicas > 1
+	}
+	return o.Spec.Nginx.Replicas != nil && *o.Spec.Nginx.Replicas > 1
+}
+
+// HasPageCache returns true if nginx caches the pages rendered by Drupal
+func (o *Nginx) HasPageCache() bool {
+	return o.Spec.Nginx.CacheSpec != nil
+}
+
+// HasVarnish returns true if Varnish gets deployed in front of nginx
+func (o *Nginx) HasVarnish() bool {
+	return o.HasPageCache() && o.Spec.Nginx.CacheSpec.Varnish != nil
+}
+
+// VarnishPodLabels return labels to apply to Varnish pods. The version label
+// is left out, as it would change the immutable Deployment selector.
+func (o *Nginx) VarnishPodLabels() labels.Set {
+	return labels.Set{
+		"app.kubernetes.io/name":      "varnish",
+		"app.kubernetes.io/instance":  o.ObjectMeta.Name,
+		"app.kubernetes.io/component": NginxVarnish.name,
+	}
+}
+
+// BackendServiceName returns the name of the Service routing to nginx
+func (o *Nginx) BackendServiceName() string {
+	// in combined topology nginx is served by the drupal Service
+	if o.IsCombined() {
+		return o.Name
+	}
+	return fmt.Sprintf("%s-%s", o.ComponentName(NginxService), "nginx")
+}
+
+// HasIngress returns true if traffic gets routed to the site by an Ingress
+func (o *Nginx) HasIngress() bool {
+	if o.Spec.IngressSpec != nil && o.Spec.IngressSpec.Disabled {
+		return false
+	}
+	return o.Spec.RoutingSpec.Mode == drupalv1beta1.IngressRoutingMode
+}
+
+// HasHTTPRoute returns true if traffic gets routed to the site by a Gateway
+// API HTTPRoute
+func (o *Nginx) HasHTTPRoute() bool {
+	return o.Spec.RoutingSpec.Mode == drupalv1beta1.GatewayRoutingMode
+}
+
+// FrontendServiceName returns the name of the Service traffic gets routed to
+func (o *Nginx) FrontendServiceName() string {
+	if o.HasVarnish() {
+		return o.ComponentName(NginxVarnish)
+	}
+	return o.BackendServiceName()
+}
+
+// HasMonitoring returns true if the nginx exporter runs alongside nginx
+func (o *Nginx) HasMonitoring() bool {
+	return o.Spec.MonitoringSpec != nil && o.Spec.MonitoringSpec.Enabled
+}
+
+// HasCertificate returns true if the site's certificate gets issued by
+// cert-manager
+func (o *Nginx) HasCertificate() bool {
+	return o.Spec.TLSSpec != nil && o.Spec.TLSSpec.IssuerRef != nil
+}
+
+// TLSSecretName returns the name of the Secret holding the site's
+// certificate, or an empty string if the site is not served over TLS
+func (o *Nginx) TLSSecretName() string {
+	if o.HasCertificate() {
+		return o.ComponentName(NginxCertificate)
+	}
+	return string(o.Spec.TLSSecretRef)
+}
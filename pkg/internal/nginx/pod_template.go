@@ -20,17 +20,35 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	drupalv1beta1 "github.com/sylus/drupal-operator/pkg/apis/drupal/v1beta1"
+	"github.com/sylus/drupal-operator/pkg/internal/drupal"
 )
 
 const (
-	nginxHTTPPort  = 80
-	nginxHTTPSPort = 443
+	nginxHTTPPort   = 80
+	nginxHTTPSPort  = 443
+	mediaVolumeName = "media"
+	// PageCachePath is where nginx keeps the cached pages
+	PageCachePath = "/var/cache/nginx/fastcgi"
+	// StubStatusPort is the loopback port nginx serves its stub_status on
+	StubStatusPort    = 8080
+	nginxExporterPort = 9113
 )
 
 var (
 	wwwDataUserID int64 = 33
 )
 
+// drupal returns the droplet wrapped as Drupal, with drupal defaults applied,
+// for sharing the drupal webroot and code with nginx
+func (droplet *Nginx) drupal() *drupal.Drupal {
+	out := drupal.New(droplet.Droplet.DeepCopy())
+	out.SetDefaults()
+	return out
+}
+
 func (droplet *Nginx) image() string {
 	return fmt.Sprintf("%s:%s", defaultImage, defaultTag)
 }
@@ -39,7 +57,7 @@ func (droplet *Nginx) env() []corev1.EnvVar {
 	out := append([]corev1.EnvVar{
 		{
 			Name:  "NGINX_HOST",
-			Value: fmt.Sprintf("http://%s", droplet.Spec.Domains[0]),
+			Value: droplet.drupal().HomeURL(),
 		},
 	}, droplet.Spec.Nginx.Env...)
 
@@ -72,6 +90,24 @@ func (droplet *Nginx) volumeMounts() (out []corev1.VolumeMount) {
 		SubPath:   "nginx.conf",
 	})
 
+	out = append(out, droplet.drupal().StaticAssetsVolumeMounts()...)
+
+	if droplet.HasMediaVolume() {
+		out = append(out, corev1.VolumeMount{
+			Name:      mediaVolumeName,
+			MountPath: droplet.drupal().MediaMountPath(),
+			ReadOnly:  true,
+			SubPath:   droplet.Spec.Drupal.MediaVolumeSpec.SubPath,
+		})
+	}
+
+	if droplet.HasPageCache() {
+		out = append(out, corev1.VolumeMount{
+			Name:      "page-cache",
+			MountPath: PageCachePath,
+		})
+	}
+
 	return out
 }
 
@@ -90,8 +126,175 @@ func (droplet *Nginx) configMap() corev1.Volume {
 	return configMap
 }
 
-func (droplet *Nginx) volumes() []corev1.Volume {
-	return append(droplet.Spec.Nginx.Volumes, droplet.configMap())
+func (droplet *Nginx) mediaVolume() corev1.Volume {
+	if droplet.Spec.Drupal.MediaVolumeSpec.HostPath == nil && droplet.Spec.Drupal.MediaVolumeSpec.PersistentVolumeClaim == nil {
+		return corev1.Volume{
+			Name: mediaVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		}
+	}
+
+	if droplet.Spec.Drupal.MediaVolumeSpec.PersistentVolumeClaim != nil {
+		return corev1.Volume{
+			Name: mediaVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: droplet.ComponentName(NginxMediaPVC),
+					ReadOnly:  true,
+				},
+			},
+		}
+	}
+
+	return corev1.Volume{
+		Name: mediaVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: droplet.Spec.Drupal.MediaVolumeSpec.HostPath,
+		},
+	}
+}
+
+// Volumes returns the volumes used by the nginx container
+func (droplet *Nginx) Volumes() []corev1.Volume {
+	out := append(droplet.Spec.Nginx.Volumes, droplet.configMap())
+	out = append(out, droplet.drupal().StaticAssetsVolumes()...)
+	if droplet.HasMediaVolume() {
+		out = append(out, droplet.mediaVolume())
+	}
+	if droplet.HasPageCache() {
+		out = append(out, corev1.Volume{
+			Name: "page-cache",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	return out
+}
+
+func (droplet *Nginx) livenessProbe() *corev1.Probe {
+	if droplet.Spec.Nginx.PodSpec != nil && droplet.Spec.Nginx.PodSpec.LivenessProbe != nil {
+		return droplet.Spec.Nginx.PodSpec.LivenessProbe
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(nginxHTTPPort),
+			},
+		},
+		InitialDelaySeconds: 10,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      5,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+	}
+}
+
+func (droplet *Nginx) readinessProbe() *corev1.Probe {
+	if droplet.Spec.Nginx.PodSpec != nil && droplet.Spec.Nginx.PodSpec.ReadinessProbe != nil {
+		return droplet.Spec.Nginx.PodSpec.ReadinessProbe
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(nginxHTTPPort),
+			},
+		},
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       5,
+		TimeoutSeconds:      5,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+	}
+}
+
+func (droplet *Nginx) resources() corev1.ResourceRequirements {
+	if droplet.Spec.Nginx.PodSpec != nil && (len(droplet.Spec.Nginx.PodSpec.Resources.Requests) > 0 || len(droplet.Spec.Nginx.PodSpec.Resources.Limits) > 0) {
+		return droplet.Spec.Nginx.PodSpec.Resources
+	}
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("250m"),
+			corev1.ResourceMemory: resource.MustParse("200Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("400m"),
+			corev1.ResourceMemory: resource.MustParse("500Mi"),
+		},
+	}
+}
+
+// setScheduling sets the scheduling overrides of spec.nginx.pod
+func (droplet *Nginx) setScheduling(out *corev1.PodSpec) {
+	// fields are always assigned, so that removed constraints get removed
+	spec := droplet.Spec.Nginx.PodSpec
+	if spec == nil {
+		spec = &drupalv1beta1.PodSpec{}
+	}
+	out.NodeSelector = spec.NodeSelector
+	out.Affinity = spec.Affinity
+	out.Tolerations = spec.Tolerations
+	out.PriorityClassName = spec.PriorityClassName
+	out.TopologySpreadConstraints = spec.TopologySpreadConstraints
+}
+
+func (droplet *Nginx) startupProbe() *corev1.Probe {
+	if droplet.Spec.Nginx.PodSpec == nil {
+		return nil
+	}
+	return droplet.Spec.Nginx.PodSpec.StartupProbe
+}
+
+// InitContainers returns the init containers which populate the volumes of
+// the nginx container
+func (droplet *Nginx) InitContainers() []corev1.Container {
+	return droplet.drupal().StaticAssetsInitContainers()
+}
+
+// Container returns the nginx container
+func (droplet *Nginx) Container() corev1.Container {
+	return corev1.Container{
+		Name:         "nginx",
+		Image:        droplet.image(),
+		VolumeMounts: droplet.volumeMounts(),
+		Env:          droplet.env(),
+		EnvFrom:      droplet.envFrom(),
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          "http",
+				ContainerPort: int32(nginxHTTPPort),
+			},
+			{
+				Name:          "https",
+				ContainerPort: int32(nginxHTTPSPort),
+			},
+		},
+		Resources:      droplet.resources(),
+		LivenessProbe:  droplet.livenessProbe(),
+		ReadinessProbe: droplet.readinessProbe(),
+		StartupProbe:   droplet.startupProbe(),
+	}
+}
+
+// ExporterContainer returns the sidecar exporting the nginx stub_status as
+// Prometheus metrics
+func (droplet *Nginx) ExporterContainer() corev1.Container {
+	return corev1.Container{
+		Name:  "nginx-exporter",
+		Image: droplet.Spec.MonitoringSpec.NginxExporterImage,
+		Args: []string{
+			fmt.Sprintf("-nginx.scrape-uri=http://127.0.0.1:%d/stub_status", StubStatusPort),
+			fmt.Sprintf("-web.listen-address=:%d", nginxExporterPort),
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          "nginx-metrics",
+				ContainerPort: int32(nginxExporterPort),
+			},
+		},
+	}
 }
 
 // PodTemplateSpec generates a pod template spec suitable for use with Nginx
@@ -99,42 +302,25 @@ func (droplet *Nginx) PodTemplateSpec() (out corev1.PodTemplateSpec) {
 	out = corev1.PodTemplateSpec{}
 	out.ObjectMeta.Labels = droplet.PodLabels()
 
-	out.Spec.ImagePullSecrets = droplet.Spec.Nginx.ImagePullSecrets
+	// the drupal runtime image is pulled for copying the webroot
+	out.Spec.ImagePullSecrets = append(droplet.Spec.Nginx.ImagePullSecrets, droplet.Spec.Drupal.ImagePullSecrets...)
 	if len(droplet.Spec.ServiceAccountName) > 0 {
 		out.Spec.ServiceAccountName = droplet.Spec.ServiceAccountName
 	}
 
+	out.Spec.InitContainers = droplet.InitContainers()
+
 	out.Spec.Containers = []corev1.Container{
-		{
-			Name:         "nginx",
-			Image:        droplet.image(),
-			VolumeMounts: droplet.volumeMounts(),
-			Env:          droplet.env(),
-			EnvFrom:      droplet.envFrom(),
-			Ports: []corev1.ContainerPort{
-				{
-					Name:          "http",
-					ContainerPort: int32(nginxHTTPPort),
-				},
-				{
-					Name:          "https",
-					ContainerPort: int32(nginxHTTPSPort),
-				},
-			},
-			Resources: corev1.ResourceRequirements{
-				Requests: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("250m"),
-					corev1.ResourceMemory: resource.MustParse("200Mi"),
-				},
-				Limits: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("400m"),
-					corev1.ResourceMemory: resource.MustParse("500Mi"),
-				},
-			},
-		},
+		droplet.Container(),
+	}
+
+	if droplet.HasMonitoring() {
+		out.Spec.Containers = append(out.Spec.Containers, droplet.ExporterContainer())
 	}
 
-	out.Spec.Volumes = droplet.volumes()
+	out.Spec.Volumes = droplet.Volumes()
+
+	droplet.setScheduling(&out.Spec)
 
 	out.Spec.SecurityContext = &corev1.PodSecurityContext{
 		FSGroup: &wwwDataUserID,
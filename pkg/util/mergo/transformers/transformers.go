@@ -30,13 +30,21 @@ type TransformerMap map[reflect.Type]func(dst, src reflect.Value) error
 // PodSpec mergo transformers for corev1.PodSpec
 var PodSpec TransformerMap
 
+// schedulingFields of corev1.PodSpec are replaced as a whole, so that
+// constraints removed from the desired spec get removed
+var schedulingFields = []string{"NodeSelector", "Affinity", "Tolerations", "PriorityClassName", "TopologySpreadConstraints"}
+
 func init() {
 	PodSpec = TransformerMap{
+		reflect.TypeOf(corev1.PodSpec{}):                PodSpec.OverrideFieldsAndMerge(schedulingFields),
+		reflect.TypeOf(corev1.Container{}):              PodSpec.OverrideFieldsAndMerge([]string{"StartupProbe"}, mergo.WithOverride),
 		reflect.TypeOf([]corev1.Container{}):            PodSpec.MergeListByKey("Name", mergo.WithOverride),
 		reflect.TypeOf([]corev1.ContainerPort{}):        PodSpec.MergeListByKey("ContainerPort", mergo.WithOverride),
 		reflect.TypeOf([]corev1.EnvVar{}):               PodSpec.MergeListByKey("Name", mergo.WithOverride),
 		reflect.TypeOf(corev1.EnvVar{}):                 PodSpec.OverrideFields("Value", "ValueFrom"),
 		reflect.TypeOf(corev1.VolumeSource{}):           PodSpec.NilOtherFields(),
+		reflect.TypeOf(corev1.Handler{}):                PodSpec.NilOtherFields(),
+		reflect.TypeOf(corev1.ResourceRequirements{}):   PodSpec.OverrideFields("Limits", "Requests"),
 		reflect.TypeOf([]corev1.Toleration{}):           PodSpec.MergeListByKey("Key", mergo.WithOverride),
 		reflect.TypeOf([]corev1.Volume{}):               PodSpec.MergeListByKey("Name", mergo.WithOverride),
 		reflect.TypeOf([]corev1.LocalObjectReference{}): PodSpec.MergeListByKey("Name", mergo.WithOverride),
@@ -164,3 +172,23 @@ func (s *TransformerMap) OverrideFields(fields ...string) func(_, _ reflect.Valu
 		return nil
 	}
 }
+
+// OverrideFieldsAndMerge overrides fields even if they are zero values (eg.
+// nil or empty list), then merges the other fields
+func (s *TransformerMap) OverrideFieldsAndMerge(fields []string, opts ...func(*mergo.Config)) func(_, _ reflect.Value) error {
+	return func(dst, src reflect.Value) error {
+		for _, field := range fields {
+			dst.FieldByName(field).Set(src.FieldByName(field))
+		}
+
+		// the other fields are merged without coming back to this transformer
+		others := TransformerMap{}
+		for t, fn := range *s {
+			if t != dst.Type() {
+				others[t] = fn
+			}
+		}
+		mergeOpts := append([]func(*mergo.Config){mergo.WithTransformers(others)}, opts...)
+		return mergo.Merge(dst.Addr().Interface(), src.Interface(), mergeOpts...)
+	}
+}
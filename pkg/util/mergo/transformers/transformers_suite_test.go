@@ -20,10 +20,10 @@ import (
 
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
-	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
 )
 
 func TestV1beta1(t *testing.T) {
 	gomega.RegisterFailHandler(ginkgo.Fail)
-	ginkgo.RunSpecsWithDefaultAndCustomReporters(t, "mergo transformers suite", []ginkgo.Reporter{envtest.NewlineReporter{}})
+	ginkgo.RunSpecsWithDefaultAndCustomReporters(t, "mergo transformers suite", []ginkgo.Reporter{printer.NewlineReporter{}})
 }
@@ -25,7 +25,9 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/sylus/drupal-operator/pkg/util/mergo/transformers"
 )
@@ -234,4 +236,64 @@ var _ = ginkgo.Describe("PodSpec Transformer", func() {
 		gomega.Expect(deployment.Spec.Template.Spec.Volumes).To(gomega.HaveLen(1))
 		gomega.Expect(deployment.Spec.Template.Spec.Volumes[0].Name).To(gomega.Equal(newSpec.Volumes[0].Name))
 	})
+	ginkgo.It("allows replacing probe handler", func() {
+		deployment.Spec.Template.Spec.Containers[0].LivenessProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				TCPSocket: &corev1.TCPSocketAction{
+					Port: intstr.FromInt(9000),
+				},
+			},
+		}
+		newSpec := deployment.Spec.Template.Spec.DeepCopy()
+		newSpec.Containers[0].LivenessProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/healthz",
+					Port: intstr.FromInt(80),
+				},
+			},
+		}
+		gomega.Expect(mergo.Merge(&deployment.Spec.Template.Spec, newSpec, mergo.WithTransformers(transformers.PodSpec))).To(gomega.Succeed())
+		gomega.Expect(deployment.Spec.Template.Spec.Containers[0].LivenessProbe.TCPSocket).To(gomega.BeNil())
+		gomega.Expect(deployment.Spec.Template.Spec.Containers[0].LivenessProbe.HTTPGet).ToNot(gomega.BeNil())
+	})
+	ginkgo.It("allows removing resource limits", func() {
+		deployment.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("400m"),
+			},
+		}
+		newSpec := deployment.Spec.Template.Spec.DeepCopy()
+		newSpec.Containers[0].Resources = corev1.ResourceRequirements{}
+		gomega.Expect(mergo.Merge(&deployment.Spec.Template.Spec, newSpec, mergo.WithTransformers(transformers.PodSpec))).To(gomega.Succeed())
+		gomega.Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Limits).To(gomega.BeEmpty())
+	})
+	ginkgo.It("allows removing tolerations", func() {
+		deployment.Spec.Template.Spec.Tolerations = []corev1.Toleration{
+			{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "drupal", Effect: corev1.TaintEffectNoSchedule},
+		}
+		deployment.Spec.Template.Spec.NodeSelector = map[string]string{"pool": "drupal"}
+		newSpec := deployment.Spec.Template.Spec.DeepCopy()
+		newSpec.Tolerations = nil
+		newSpec.NodeSelector = nil
+		gomega.Expect(mergo.Merge(&deployment.Spec.Template.Spec, newSpec, mergo.WithTransformers(transformers.PodSpec))).To(gomega.Succeed())
+		gomega.Expect(deployment.Spec.Template.Spec.Tolerations).To(gomega.BeEmpty())
+		gomega.Expect(deployment.Spec.Template.Spec.NodeSelector).To(gomega.BeEmpty())
+		gomega.Expect(deployment.Spec.Template.Spec.Containers).To(gomega.HaveLen(2))
+	})
+	ginkgo.It("allows removing the startup probe", func() {
+		deployment.Spec.Template.Spec.Containers[0].StartupProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				TCPSocket: &corev1.TCPSocketAction{
+					Port: intstr.FromInt(9000),
+				},
+			},
+		}
+		newSpec := deployment.Spec.Template.Spec.DeepCopy()
+		newSpec.Containers[0].StartupProbe = nil
+		newSpec.Containers[0].Image = "main-image:2"
+		gomega.Expect(mergo.Merge(&deployment.Spec.Template.Spec, newSpec, mergo.WithTransformers(transformers.PodSpec))).To(gomega.Succeed())
+		gomega.Expect(deployment.Spec.Template.Spec.Containers[0].StartupProbe).To(gomega.BeNil())
+		gomega.Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(gomega.Equal("main-image:2"))
+	})
 })
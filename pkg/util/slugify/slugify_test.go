@@ -0,0 +1,37 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slugify_test
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	"github.com/onsi/gomega"
+
+	"github.com/sylus/drupal-operator/pkg/util/slugify"
+)
+
+var _ = ginkgo.Describe("Slugify", func() {
+	table.DescribeTable("returns a lowercase, dash separated value",
+		func(in, out string) {
+			gomega.Expect(slugify.Slugify(in)).To(gomega.Equal(out))
+		},
+		table.Entry("keeps simple values", "latest", "latest"),
+		table.Entry("lowercases", "7.2-FPM", "7-2-fpm"),
+		table.Entry("collapses separators", "8.6  -- apache", "8-6-apache"),
+		table.Entry("trims separators", "_v1.0_", "v1-0"),
+		table.Entry("returns nothing for empty values", "", ""),
+	)
+})
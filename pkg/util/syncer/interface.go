@@ -22,8 +22,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// MutateFn mutates the existing object, as read from the apiserver, into the
+// desired state
+type MutateFn func(existing runtime.Object) error
+
 // SyncResult is a result of an Sync call
 type SyncResult struct {
+	// Name of the syncer which produced the result
+	Name         string
 	Operation    controllerutil.OperationResult
 	EventType    string
 	EventReason  string
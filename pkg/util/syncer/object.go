@@ -23,7 +23,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
-	"sigs.k8s.io/controller-runtime/pkg/patch"
 )
 
 // ObjectSyncer is a syncer.Interface for syncing kubernetes.Objects only by
@@ -31,7 +30,7 @@ import (
 type ObjectSyncer struct {
 	Owner          runtime.Object
 	Obj            runtime.Object
-	SyncFn         controllerutil.MutateFn
+	SyncFn         MutateFn
 	Name           string
 	Client         client.Client
 	Scheme         *runtime.Scheme
@@ -46,16 +45,25 @@ func (s *ObjectSyncer) GetOwner() runtime.Object { return s.Owner }
 
 // Sync does the actual syncing and implements the syncer.Inteface Sync method
 func (s *ObjectSyncer) Sync(ctx context.Context) (SyncResult, error) {
-	result := SyncResult{}
+	result := SyncResult{Name: s.Name}
 
 	key, err := getKey(s.Obj)
 	if err != nil {
 		return result, err
 	}
 
-	result.Operation, err = controllerutil.CreateOrUpdate(ctx, s.Client, s.Obj, s.mutateFn())
+	obj, ok := s.Obj.(client.Object)
+	if !ok {
+		return result, fmt.Errorf("%T is not a client.Object", s.Obj)
+	}
+
+	result.Operation, err = controllerutil.CreateOrUpdate(ctx, s.Client, obj, s.mutateFn())
 
-	diff, _ := patch.NewJSONPatch(s.previousObject, s.Obj)
+	var diff string
+	if previous, ok := s.previousObject.(client.Object); ok {
+		patch, _ := client.MergeFrom(previous).Data(obj)
+		diff = string(patch)
+	}
 
 	if err != nil {
 		result.SetEventData(eventWarning, basicEventReason(s.Name, err),
@@ -70,10 +78,12 @@ func (s *ObjectSyncer) Sync(ctx context.Context) (SyncResult, error) {
 	return result, err
 }
 
-// Given an ObjectSyncer, returns a controllerutil.MutateFn which also sets the
-// owner reference if the subject has one
+// Given an ObjectSyncer, returns a controllerutil.MutateFn which runs the
+// SyncFn on the subject, as read by CreateOrUpdate, and also sets the owner
+// reference if the subject has one
 func (s *ObjectSyncer) mutateFn() controllerutil.MutateFn {
-	return func(existing runtime.Object) error {
+	return func() error {
+		existing := s.Obj
 		s.previousObject = existing.DeepCopyObject()
 		err := s.SyncFn(existing)
 		if err != nil {
@@ -101,7 +111,7 @@ func (s *ObjectSyncer) mutateFn() controllerutil.MutateFn {
 // with an owner and persists data using controller-runtime's CreateOrUpdate.
 // The name is used for logging and event emitting purposes and should be an
 // valid go identifier in upper camel case. (eg. MysqlStatefulSet)
-func NewObjectSyncer(name string, owner, obj runtime.Object, c client.Client, scheme *runtime.Scheme, syncFn controllerutil.MutateFn) Interface {
+func NewObjectSyncer(name string, owner, obj runtime.Object, c client.Client, scheme *runtime.Scheme, syncFn MutateFn) Interface {
 	return &ObjectSyncer{
 		Owner:  owner,
 		Obj:    obj,
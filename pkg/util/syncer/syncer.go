@@ -25,7 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
-	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var log = logf.Log.WithName("syncer")
@@ -58,6 +58,13 @@ func basicEventReason(objKindName string, err error) string {
 // CreateOrUpdate method, when obj is not nil. It takes care of setting owner
 // references and recording kubernetes events where appropriate
 func Sync(ctx context.Context, syncer Interface, recorder record.EventRecorder) error {
+	_, err := SyncWithResult(ctx, syncer, recorder)
+	return err
+}
+
+// SyncWithResult works like Sync and additionally returns the SyncResult, eg.
+// for recording metrics
+func SyncWithResult(ctx context.Context, syncer Interface, recorder record.EventRecorder) (SyncResult, error) {
 	result, err := syncer.Sync(ctx)
 	owner := syncer.GetOwner()
 
@@ -67,7 +74,7 @@ func Sync(ctx context.Context, syncer Interface, recorder record.EventRecorder)
 		}
 	}
 
-	return err
+	return result, err
 }
 
 // WithoutOwner partially implements the syncer interface for the case the subject has no owner
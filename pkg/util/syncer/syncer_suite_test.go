@@ -24,6 +24,7 @@ import (
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
 )
 
 var t *envtest.Environment
@@ -32,7 +33,7 @@ var c client.Client
 
 func TestV1beta1(t *testing.T) {
 	gomega.RegisterFailHandler(ginkgo.Fail)
-	ginkgo.RunSpecsWithDefaultAndCustomReporters(t, "API v1 Suite", []ginkgo.Reporter{envtest.NewlineReporter{}})
+	ginkgo.RunSpecsWithDefaultAndCustomReporters(t, "API v1 Suite", []ginkgo.Reporter{printer.NewlineReporter{}})
 }
 
 var _ = ginkgo.BeforeSuite(func() {
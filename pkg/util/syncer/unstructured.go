@@ -0,0 +1,161 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// UnstructuredSyncer is a syncer.Interface for syncing objects of kinds which
+// are not part of the scheme, eg. custom resources of other projects, using
+// the dynamic client
+type UnstructuredSyncer struct {
+	Owner    runtime.Object
+	Obj      *unstructured.Unstructured
+	Resource schema.GroupVersionResource
+	SyncFn   MutateFn
+	Name     string
+	Client   dynamic.Interface
+	Scheme   *runtime.Scheme
+}
+
+// GetObject returns the UnstructuredSyncer subject
+func (s *UnstructuredSyncer) GetObject() interface{} { return s.Obj }
+
+// GetOwner returns the UnstructuredSyncer owner
+func (s *UnstructuredSyncer) GetOwner() runtime.Object { return s.Owner }
+
+// Sync does the actual syncing and implements the syncer.Inteface Sync method
+func (s *UnstructuredSyncer) Sync(ctx context.Context) (SyncResult, error) {
+	result := SyncResult{Name: s.Name}
+	key := fmt.Sprintf("%s/%s", s.Obj.GetNamespace(), s.Obj.GetName())
+
+	var err error
+	result.Operation, err = s.createOrUpdate(ctx)
+
+	if err != nil {
+		result.SetEventData(eventWarning, basicEventReason(s.Name, err),
+			fmt.Sprintf("%s %s failed syncing: %s", s.Obj.GetKind(), key, err))
+		log.Error(err, string(result.Operation), "key", key, "kind", s.Obj.GetKind())
+	} else {
+		result.SetEventData(eventNormal, basicEventReason(s.Name, err),
+			fmt.Sprintf("%s %s %s successfully", s.Obj.GetKind(), key, result.Operation))
+		log.V(1).Info(string(result.Operation), "key", key, "kind", s.Obj.GetKind())
+	}
+
+	return result, err
+}
+
+func (s *UnstructuredSyncer) createOrUpdate(ctx context.Context) (controllerutil.OperationResult, error) {
+	client := s.Client.Resource(s.Resource).Namespace(s.Obj.GetNamespace())
+
+	existing, err := client.Get(ctx, s.Obj.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if err = s.mutate(s.Obj); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		created, err := client.Create(ctx, s.Obj, metav1.CreateOptions{})
+		if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		s.Obj.Object = created.Object
+		return controllerutil.OperationResultCreated, nil
+	} else if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	// keep the subject's gvk, the dynamic client returns it as is
+	existing.SetGroupVersionKind(s.Obj.GroupVersionKind())
+	s.Obj.Object = existing.Object
+	previous := existing.DeepCopy()
+	if err = s.mutate(s.Obj); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	if reflect.DeepEqual(previous.Object, s.Obj.Object) {
+		return controllerutil.OperationResultNone, nil
+	}
+
+	updated, err := client.Update(ctx, s.Obj, metav1.UpdateOptions{})
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	s.Obj.Object = updated.Object
+	return controllerutil.OperationResultUpdated, nil
+}
+
+// mutate runs the SyncFn and sets the owner reference if the subject has one
+func (s *UnstructuredSyncer) mutate(obj *unstructured.Unstructured) error {
+	if err := s.SyncFn(obj); err != nil {
+		return err
+	}
+	if s.Owner != nil {
+		ownerMeta, ok := s.Owner.(metav1.Object)
+		if !ok {
+			return fmt.Errorf("%T is not a metav1.Object", s.Owner)
+		}
+		return controllerutil.SetControllerReference(ownerMeta, obj, s.Scheme)
+	}
+	return nil
+}
+
+// Delete deletes the subject if it exists and is controlled by the owner
+func (s *UnstructuredSyncer) Delete(ctx context.Context) error {
+	client := s.Client.Resource(s.Resource).Namespace(s.Obj.GetNamespace())
+
+	existing, err := client.Get(ctx, s.Obj.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	ownerMeta, ok := s.Owner.(metav1.Object)
+	if !ok || !metav1.IsControlledBy(existing, ownerMeta) {
+		return nil
+	}
+
+	err = client.Delete(ctx, s.Obj.GetName(), metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// NewUnstructuredSyncer creates a new syncer for a given unstructured object
+// with an owner and persists data using the dynamic client. The name is used
+// for logging and event emitting purposes and should be an valid go
+// identifier in upper camel case. (eg. HTTPRoute)
+func NewUnstructuredSyncer(name string, owner runtime.Object, obj *unstructured.Unstructured, resource schema.GroupVersionResource, c dynamic.Interface, scheme *runtime.Scheme, syncFn MutateFn) Interface {
+	return &UnstructuredSyncer{
+		Owner:    owner,
+		Obj:      obj,
+		Resource: resource,
+		SyncFn:   syncFn,
+		Name:     name,
+		Client:   c,
+		Scheme:   scheme,
+	}
+}